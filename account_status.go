@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+)
+
+const (
+	accountStatusSuspended = "suspended"
+	accountStatusBanned    = "banned"
+)
+
+// accountRestriction is the 403 body returned to suspended and banned users,
+// so clients can tell them why they've been refused and for how long.
+type accountRestriction struct {
+	Error          string     `json:"error"`
+	Status         string     `json:"status"`
+	Reason         string     `json:"reason,omitempty"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+}
+
+// restrictionFor reports whether user is currently suspended or banned. A ban
+// takes precedence over a suspension.
+func restrictionFor(user database.User, now time.Time) (accountRestriction, bool) {
+	if user.BannedAt.Valid {
+		return accountRestriction{
+			Error:  "this account has been banned",
+			Status: accountStatusBanned,
+			Reason: user.ModerationReason,
+		}, true
+	}
+	if user.SuspendedUntil.Valid && user.SuspendedUntil.Time.After(now) {
+		return accountRestriction{
+			Error:          "this account is suspended",
+			Status:         accountStatusSuspended,
+			Reason:         user.ModerationReason,
+			SuspendedUntil: &user.SuspendedUntil.Time,
+		}, true
+	}
+	return accountRestriction{}, false
+}
+
+// requireActiveAccount writes a 403 and returns false when user is suspended
+// or banned.
+func requireActiveAccount(w http.ResponseWriter, user database.User) bool {
+	restriction, restricted := restrictionFor(user, time.Now().UTC())
+	if restricted {
+		respondWithJSON(w, http.StatusForbidden, restriction)
+		return false
+	}
+	return true
+}
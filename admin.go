@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	roleUser      = "user"
+	roleModerator = "moderator"
+	roleAdmin     = "admin"
+)
+
+// roleRank orders roles from least to most privileged. Unknown roles rank
+// with plain users.
+func roleRank(role string) int {
+	switch role {
+	case roleAdmin:
+		return 2
+	case roleModerator:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// requireRole authenticates the request and checks that the caller holds one
+// of roles. It writes the error response itself, so callers only need to
+// return when ok is false.
+func (cfg *apiConfig) requireRole(w http.ResponseWriter, req *http.Request, roles ...string) (user database.User, ok bool) {
+	user, ok = cfg.requireAccount(w, req)
+	if !ok {
+		return database.User{}, false
+	}
+
+	for _, role := range roles {
+		if user.Role == role {
+			return user, true
+		}
+	}
+
+	respondWithError(w, req, http.StatusForbidden, "insufficient privileges", nil)
+	return database.User{}, false
+}
+
+// requireUser authenticates the request and returns the caller's ID, writing
+// a 401 response when the access token is missing or invalid and a 403 when
+// the account is suspended or banned.
+func (cfg *apiConfig) requireUser(w http.ResponseWriter, req *http.Request) (uuid.UUID, bool) {
+	user, ok := cfg.requireAccount(w, req)
+	return user.ID, ok
+}
+
+// requireAccount is requireUser for handlers that also need the caller's
+// account.
+func (cfg *apiConfig) requireAccount(w http.ResponseWriter, req *http.Request) (database.User, bool) {
+	a := cfg.authenticate(req)
+	if a.tokenErr != nil {
+		respondWithError(w, req, http.StatusUnauthorized, "Couldn't find JWT", a.tokenErr)
+		return database.User{}, false
+	}
+	if a.validateErr != nil {
+		respondWithError(w, req, http.StatusUnauthorized, "Couldn't validate JWT", a.validateErr)
+		return database.User{}, false
+	}
+
+	return cfg.activeAccount(w, req, a.userID)
+}
+
+// activeAccount loads an authenticated caller's account and refuses it when
+// it's suspended or banned. Access tokens outlive a suspension's start, so
+// this is checked on every request rather than only at login.
+func (cfg *apiConfig) activeAccount(w http.ResponseWriter, req *http.Request, userID uuid.UUID) (database.User, bool) {
+	user, err := cfg.db.GetUserByID(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, req, http.StatusUnauthorized, "Couldn't find user", err)
+		return database.User{}, false
+	}
+	if !requireActiveAccount(w, user) {
+		return database.User{}, false
+	}
+	return user, true
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/logging"
+	"github.com/google/uuid"
+)
+
+const (
+	auditLoginSucceeded  = "auth.login_succeeded"
+	auditLoginFailed     = "auth.login_failed"
+	auditTokenRefreshed  = "auth.token_refreshed"
+	auditTokenRevoked    = "auth.token_revoked"
+	auditPasswordChanged = "user.password_changed"
+	auditEmailChanged    = "user.email_changed"
+	auditUserUpgraded    = "subscription.upgraded"
+	auditChirpRemoved    = "moderation.chirp_removed"
+	auditDatabaseReset   = "admin.database_reset"
+)
+
+const maxAuditUserAgentBytes = 512
+
+// auditEvent describes one entry in the audit log. ActorID is the user who
+// acted and TargetID the user acted upon; either is uuid.Nil when unknown.
+type auditEvent struct {
+	Type      string
+	ActorID   uuid.UUID
+	TargetID  uuid.UUID
+	IP        string
+	UserAgent string
+	Payload   any
+}
+
+// newAuditEvent starts an event of the given type from the client IP and user
+// agent of req.
+func (cfg *apiConfig) newAuditEvent(req *http.Request, eventType string) auditEvent {
+	userAgent := req.UserAgent()
+	if len(userAgent) > maxAuditUserAgentBytes {
+		userAgent = userAgent[:maxAuditUserAgentBytes]
+	}
+	return auditEvent{
+		Type:      eventType,
+		IP:        clientIP(req, cfg.trustProxy),
+		UserAgent: userAgent,
+	}
+}
+
+// recordAuditEvent writes e using q, so it can share a transaction with the
+// change it describes.
+func recordAuditEvent(ctx context.Context, q database.Querier, e auditEvent) error {
+	payload := json.RawMessage("{}")
+	if e.Payload != nil {
+		dat, err := json.Marshal(e.Payload)
+		if err != nil {
+			return err
+		}
+		payload = dat
+	}
+
+	return q.CreateAuditEvent(ctx, database.CreateAuditEventParams{
+		EventType: e.Type,
+		ActorID:   uuid.NullUUID{UUID: e.ActorID, Valid: e.ActorID != uuid.Nil},
+		TargetID:  uuid.NullUUID{UUID: e.TargetID, Valid: e.TargetID != uuid.Nil},
+		Ip:        e.IP,
+		UserAgent: e.UserAgent,
+		Payload:   payload,
+	})
+}
+
+// audit records e outside of any transaction. A failure is logged rather than
+// failing a request that has already done its work.
+func (cfg *apiConfig) audit(ctx context.Context, e auditEvent) {
+	err := recordAuditEvent(ctx, cfg.db, e)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to record audit event", "type", e.Type, "error", err)
+	}
+}
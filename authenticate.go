@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/CybrRonin/Chirpy/internal/auth"
+	"github.com/google/uuid"
+)
+
+type contextKey int
+
+const authenticationKey contextKey = iota
+
+// authentication is the outcome of checking a request's bearer token.
+// tokenErr is set when there's no token and validateErr when it's invalid.
+type authentication struct {
+	userID      uuid.UUID
+	tokenErr    error
+	validateErr error
+}
+
+// middlewareAuthenticate checks the access token once and keeps the result
+// in the request context, so the access log, rate limiter and handlers don't
+// each parse the JWT again. It doesn't reject anything; handlers that need a
+// user still call requireUser or requireAccount.
+func (cfg *apiConfig) middlewareAuthenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), authenticationKey, cfg.authenticate(req))
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// authenticate returns the result middlewareAuthenticate stored, or checks
+// the token itself when the request didn't pass through it.
+func (cfg *apiConfig) authenticate(req *http.Request) authentication {
+	if a, ok := req.Context().Value(authenticationKey).(authentication); ok {
+		return a
+	}
+
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		return authentication{tokenErr: err}
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		return authentication{validateErr: err}
+	}
+	return authentication{userID: userID}
+}
+
+// authenticatedUserID returns the user behind a valid access token, if any.
+func (cfg *apiConfig) authenticatedUserID(req *http.Request) (uuid.UUID, bool) {
+	a := cfg.authenticate(req)
+	return a.userID, a.tokenErr == nil && a.validateErr == nil
+}
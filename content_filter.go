@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/contentfilter"
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/logging"
+)
+
+type ContentFilterWord struct {
+	Word      string    `json:"word"`
+	Action    string    `json:"action"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// contentFilterWordsFromDB loads the word list from the content_filter_words table.
+func contentFilterWordsFromDB(q database.Querier) contentfilter.Source {
+	return contentfilter.SourceFunc(func(ctx context.Context) ([]contentfilter.Rule, error) {
+		words, err := q.ListContentFilterWords(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		rules := make([]contentfilter.Rule, 0, len(words))
+		for _, w := range words {
+			action, err := contentfilter.ParseAction(w.Action)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, contentfilter.Rule{Word: w.Word, Action: action})
+		}
+		return rules, nil
+	})
+}
+
+func (cfg *apiConfig) handlerContentFilterWordsList(w http.ResponseWriter, req *http.Request) {
+	_, ok := cfg.requireRole(w, req, roleModerator, roleAdmin)
+	if !ok {
+		return
+	}
+
+	words, err := cfg.db.ListContentFilterWords(req.Context())
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't retrieve content filter words", err)
+		return
+	}
+
+	resp := make([]ContentFilterWord, 0, len(words))
+	for _, word := range words {
+		resp = append(resp, mapContentFilterWord(word))
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// handlerContentFilterWordsUpsert adds a word or changes its action. The
+// change applies immediately when the filter is backed by the database.
+func (cfg *apiConfig) handlerContentFilterWordsUpsert(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Word   string `json:"word"`
+		Action string `json:"action"`
+	}
+
+	_, ok := cfg.requireRole(w, req, roleAdmin)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(req.Body, &params)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "couldn't decode parameters", err)
+		return
+	}
+	if params.Word == "" {
+		respondWithError(w, req, http.StatusBadRequest, "word is required", nil)
+		return
+	}
+	action, err := contentfilter.ParseAction(params.Action)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	word, err := cfg.db.UpsertContentFilterWord(req.Context(), database.UpsertContentFilterWordParams{
+		Word:   params.Word,
+		Action: string(action),
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't save content filter word", err)
+		return
+	}
+
+	cfg.reloadContentFilter(req.Context())
+	respondWithJSON(w, http.StatusOK, mapContentFilterWord(word))
+}
+
+func (cfg *apiConfig) handlerContentFilterWordsDelete(w http.ResponseWriter, req *http.Request) {
+	_, ok := cfg.requireRole(w, req, roleAdmin)
+	if !ok {
+		return
+	}
+
+	deleted, err := cfg.db.DeleteContentFilterWord(req.Context(), req.PathValue("word"))
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't delete content filter word", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, req, http.StatusNotFound, "couldn't find content filter word", nil)
+		return
+	}
+
+	cfg.reloadContentFilter(req.Context())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reloadContentFilter picks up a word list change without waiting for the
+// next scheduled reload. Failures leave the current rules in place.
+func (cfg *apiConfig) reloadContentFilter(ctx context.Context) {
+	if cfg.contentFilterSource == nil {
+		return
+	}
+	err := cfg.contentFilter.Reload(ctx, cfg.contentFilterSource)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to reload content filter", "error", err)
+	}
+}
+
+func mapContentFilterWord(w database.ContentFilterWord) ContentFilterWord {
+	return ContentFilterWord{
+		Word:      w.Word,
+		Action:    w.Action,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
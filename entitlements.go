@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/CybrRonin/Chirpy/internal/entitlements"
+	"github.com/CybrRonin/Chirpy/internal/logging"
+	"github.com/google/uuid"
+)
+
+// entitlementsFor derives a user's perks from their current subscription.
+// Users without one are on the free plan.
+func (cfg *apiConfig) entitlementsFor(ctx context.Context, userID uuid.UUID) (entitlements.Entitlements, error) {
+	plan, err := cfg.db.GetActivePlan(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entitlements.ForPlan(entitlements.PlanFree), nil
+	}
+	if err != nil {
+		return entitlements.Entitlements{}, err
+	}
+	return entitlements.ForPlan(entitlements.Plan(plan)), nil
+}
+
+func (cfg *apiConfig) isChirpyRed(ctx context.Context, userID uuid.UUID) bool {
+	ent, err := cfg.entitlementsFor(ctx, userID)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to look up subscription", "user_id", userID, "error", err)
+		return false
+	}
+	return ent.Plan == entitlements.PlanRed
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/logging"
+	"github.com/CybrRonin/Chirpy/internal/realtime"
+	"github.com/CybrRonin/Chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+// publishEvent notifies realtime subscribers. It runs after the change has
+// been committed, and a failure only costs subscribers a live update.
+func (cfg *apiConfig) publishEvent(ctx context.Context, eventType string, authorID uuid.UUID, topics []string, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to encode realtime event", "type", eventType, "error", err)
+		return
+	}
+
+	err = cfg.events.Publish(ctx, realtime.Event{
+		Type:     eventType,
+		AuthorID: authorID,
+		Topics:   topics,
+		Data:     data,
+	})
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to publish realtime event", "type", eventType, "error", err)
+	}
+}
+
+// closeStreams ends every SSE and websocket stream. http.Server.Shutdown
+// waits for connections to go idle, which long-lived streams never do, and
+// doesn't track hijacked websocket connections at all.
+func (cfg *apiConfig) closeStreams() {
+	close(cfg.streamsClosed)
+}
+
+// streamable reports whether events about chirp can go to live streams and
+// webhook subscribers. Neither is access controlled, so only chirps everyone
+// can read qualify: public, not hidden by a moderator, and from an
+// unprotected account whose chirps aren't hidden by a suspension or ban.
+func (cfg *apiConfig) streamable(ctx context.Context, chirp Chirp) bool {
+	if chirp.Visibility != chirpVisibilityPublic || chirp.Hidden {
+		return false
+	}
+	author, err := cfg.db.GetUserByID(ctx, chirp.UserID)
+	if err != nil || author.Protected {
+		return false
+	}
+	_, restricted := restrictionFor(author, time.Now().UTC())
+	return !(restricted && author.ChirpsHidden)
+}
+
+// enqueueChirpWebhook queues a chirp event for webhook subscribers using q,
+// skipping chirps that aren't streamable.
+func (cfg *apiConfig) enqueueChirpWebhook(ctx context.Context, q database.Querier, eventType string, chirp Chirp) error {
+	if !cfg.streamable(ctx, chirp) {
+		return nil
+	}
+	return webhooks.Enqueue(ctx, q, eventType, chirp)
+}
+
+func (cfg *apiConfig) publishChirpEvent(ctx context.Context, eventType string, chirp Chirp) {
+	if !cfg.streamable(ctx, chirp) {
+		return
+	}
+
+	topics := []string{
+		realtime.TopicFeed,
+		realtime.UserTopic(chirp.UserID),
+		realtime.ThreadTopic(chirp.ID),
+	}
+	if chirp.ParentID != nil {
+		topics = append(topics, realtime.ThreadTopic(*chirp.ParentID))
+	}
+	cfg.publishEvent(ctx, eventType, chirp.UserID, topics, chirp)
+}
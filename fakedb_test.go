@@ -0,0 +1,578 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/auth"
+	"github.com/CybrRonin/Chirpy/internal/contentfilter"
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/metrics"
+	"github.com/CybrRonin/Chirpy/internal/realtime"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const testJWTSecret = "test-secret"
+
+// fakeDB is an in-memory database.Querier for handler tests. It implements
+// the queries the tested handlers use; any other query panics through the
+// nil embedded interface, which fails the test that made it.
+type fakeDB struct {
+	database.Querier
+
+	mu sync.Mutex
+	fakeState
+}
+
+type fakeState struct {
+	users         map[uuid.UUID]database.User
+	chirps        map[uuid.UUID]database.Chirp
+	subscriptions map[uuid.UUID]database.Subscription
+	webhookEvents []database.WebhookEvent
+	outbox        []database.CreateWebhookOutboxEventParams
+	reports       []database.Report
+	modActions    []database.CreateModerationActionParams
+	auditEvents   []database.CreateAuditEventParams
+	notifications []database.Notification
+	follows       []database.Follow
+	mentions      map[uuid.UUID][]uuid.UUID
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{fakeState: fakeState{
+		users:         map[uuid.UUID]database.User{},
+		chirps:        map[uuid.UUID]database.Chirp{},
+		subscriptions: map[uuid.UUID]database.Subscription{},
+		mentions:      map[uuid.UUID][]uuid.UUID{},
+	}}
+}
+
+func (s fakeState) clone() fakeState {
+	return fakeState{
+		users:         maps.Clone(s.users),
+		chirps:        maps.Clone(s.chirps),
+		subscriptions: maps.Clone(s.subscriptions),
+		webhookEvents: slices.Clone(s.webhookEvents),
+		outbox:        slices.Clone(s.outbox),
+		reports:       slices.Clone(s.reports),
+		modActions:    slices.Clone(s.modActions),
+		auditEvents:   slices.Clone(s.auditEvents),
+		notifications: slices.Clone(s.notifications),
+		follows:       slices.Clone(s.follows),
+		mentions:      maps.Clone(s.mentions),
+	}
+}
+
+// withTx runs fn against the fake, restoring its previous state when fn
+// fails the way a rolled-back transaction would.
+func (db *fakeDB) withTx(ctx context.Context, fn func(q database.Querier) error) error {
+	db.mu.Lock()
+	saved := db.fakeState.clone()
+	db.mu.Unlock()
+
+	err := fn(db)
+	if err != nil {
+		db.mu.Lock()
+		db.fakeState = saved
+		db.mu.Unlock()
+	}
+	return err
+}
+
+func (db *fakeDB) addUser(role string) database.User {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	now := time.Now().UTC()
+	user := database.User{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Email:     uuid.NewString() + "@example.com",
+		Role:      role,
+	}
+	db.users[user.ID] = user
+	return user
+}
+
+func (db *fakeDB) addChirp(userID uuid.UUID, body, visibility string) database.Chirp {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	now := time.Now().UTC()
+	chirp := database.Chirp{
+		ID:         uuid.New(),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Body:       body,
+		UserID:     userID,
+		Visibility: visibility,
+	}
+	db.chirps[chirp.ID] = chirp
+	return chirp
+}
+
+func (db *fakeDB) GetUserByID(ctx context.Context, id uuid.UUID) (database.User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	user, ok := db.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (db *fakeDB) CreateAuditEvent(ctx context.Context, arg database.CreateAuditEventParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.auditEvents = append(db.auditEvents, arg)
+	return nil
+}
+
+func (db *fakeDB) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	n := database.Notification{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UserID:    arg.UserID,
+		ActorID:   arg.ActorID,
+		Type:      arg.Type,
+		ChirpID:   arg.ChirpID,
+	}
+	db.notifications = append(db.notifications, n)
+	return n, nil
+}
+
+func (db *fakeDB) CreateWebhookOutboxEvent(ctx context.Context, arg database.CreateWebhookOutboxEventParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.outbox = append(db.outbox, arg)
+	return nil
+}
+
+func (db *fakeDB) GetActivePlan(ctx context.Context, userID uuid.UUID) (string, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	sub, ok := db.subscriptions[userID]
+	if !ok || sub.Status == subscriptionStatusExpired || !sub.CurrentPeriodEnd.After(time.Now()) {
+		return "", sql.ErrNoRows
+	}
+	return sub.Plan, nil
+}
+
+func (db *fakeDB) GetSubscriptionByUser(ctx context.Context, userID uuid.UUID) (database.Subscription, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	sub, ok := db.subscriptions[userID]
+	if !ok {
+		return database.Subscription{}, sql.ErrNoRows
+	}
+	return sub, nil
+}
+
+// UpsertSubscription fails like the users foreign key when the user is
+// missing.
+func (db *fakeDB) UpsertSubscription(ctx context.Context, arg database.UpsertSubscriptionParams) (database.Subscription, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.users[arg.UserID]; !ok {
+		return database.Subscription{}, &pq.Error{Code: "23503", Constraint: "subscriptions_user_id_fkey"}
+	}
+	now := time.Now().UTC()
+	sub, ok := db.subscriptions[arg.UserID]
+	if !ok {
+		sub = database.Subscription{ID: uuid.New(), CreatedAt: now, UserID: arg.UserID}
+	}
+	sub.UpdatedAt = now
+	sub.Plan = arg.Plan
+	sub.Status = arg.Status
+	sub.CurrentPeriodEnd = arg.CurrentPeriodEnd
+	db.subscriptions[arg.UserID] = sub
+	return sub, nil
+}
+
+func (db *fakeDB) UpdateSubscriptionStatus(ctx context.Context, arg database.UpdateSubscriptionStatusParams) (database.Subscription, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	sub, ok := db.subscriptions[arg.UserID]
+	if !ok {
+		return database.Subscription{}, sql.ErrNoRows
+	}
+	sub.Status = arg.Status
+	db.subscriptions[arg.UserID] = sub
+	return sub, nil
+}
+
+// CreateWebhookEvent returns sql.ErrNoRows when the provider has already
+// sent the event ID, like its ON CONFLICT DO NOTHING.
+func (db *fakeDB) CreateWebhookEvent(ctx context.Context, arg database.CreateWebhookEventParams) (database.WebhookEvent, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, e := range db.webhookEvents {
+		if arg.EventID.Valid && e.Provider == arg.Provider && e.EventID == arg.EventID {
+			return database.WebhookEvent{}, sql.ErrNoRows
+		}
+	}
+	now := time.Now().UTC()
+	event := database.WebhookEvent{
+		ID:         uuid.New(),
+		Provider:   arg.Provider,
+		EventID:    arg.EventID,
+		EventType:  arg.EventType,
+		Payload:    arg.Payload,
+		Status:     webhookStatusPending,
+		ReceivedAt: now,
+		UpdatedAt:  now,
+	}
+	db.webhookEvents = append(db.webhookEvents, event)
+	return event, nil
+}
+
+func (db *fakeDB) GetWebhookEventByEventID(ctx context.Context, arg database.GetWebhookEventByEventIDParams) (database.WebhookEvent, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, e := range db.webhookEvents {
+		if e.Provider == arg.Provider && e.EventID == arg.EventID {
+			return e, nil
+		}
+	}
+	return database.WebhookEvent{}, sql.ErrNoRows
+}
+
+func (db *fakeDB) GetWebhookEventForUpdate(ctx context.Context, id uuid.UUID) (database.WebhookEvent, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, e := range db.webhookEvents {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return database.WebhookEvent{}, sql.ErrNoRows
+}
+
+func (db *fakeDB) updateWebhookEvent(id uuid.UUID, update func(e *database.WebhookEvent)) (database.WebhookEvent, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i := range db.webhookEvents {
+		if db.webhookEvents[i].ID == id {
+			update(&db.webhookEvents[i])
+			return db.webhookEvents[i], nil
+		}
+	}
+	return database.WebhookEvent{}, sql.ErrNoRows
+}
+
+func (db *fakeDB) MarkWebhookEventProcessed(ctx context.Context, arg database.MarkWebhookEventProcessedParams) (database.WebhookEvent, error) {
+	return db.updateWebhookEvent(arg.ID, func(e *database.WebhookEvent) {
+		e.Status = arg.Status
+		e.Error = sql.NullString{}
+		e.Attempts++
+		e.ProcessedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	})
+}
+
+func (db *fakeDB) MarkWebhookEventFailed(ctx context.Context, arg database.MarkWebhookEventFailedParams) (database.WebhookEvent, error) {
+	return db.updateWebhookEvent(arg.ID, func(e *database.WebhookEvent) {
+		e.Status = webhookStatusFailed
+		e.Error = arg.Error
+		e.Attempts++
+	})
+}
+
+func (db *fakeDB) updateUser(id uuid.UUID, update func(u *database.User)) (database.User, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	user, ok := db.users[id]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	update(&user)
+	user.UpdatedAt = time.Now().UTC()
+	db.users[id] = user
+	return user, nil
+}
+
+func (db *fakeDB) UpdateUser(ctx context.Context, arg database.UpdateUserParams) (database.User, error) {
+	return db.updateUser(arg.ID, func(u *database.User) {
+		u.Email = arg.Email
+		u.HashedPassword = arg.HashedPassword
+	})
+}
+
+func (db *fakeDB) SuspendUser(ctx context.Context, arg database.SuspendUserParams) (database.User, error) {
+	return db.updateUser(arg.ID, func(u *database.User) {
+		u.SuspendedUntil = arg.SuspendedUntil
+		u.ModerationReason = arg.ModerationReason
+		u.ChirpsHidden = arg.ChirpsHidden
+	})
+}
+
+func (db *fakeDB) BanUser(ctx context.Context, arg database.BanUserParams) (database.User, error) {
+	return db.updateUser(arg.ID, func(u *database.User) {
+		if !u.BannedAt.Valid {
+			u.BannedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		}
+		u.ModerationReason = arg.ModerationReason
+		u.ChirpsHidden = arg.ChirpsHidden
+	})
+}
+
+func (db *fakeDB) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
+func (db *fakeDB) ResolveReportsForUser(ctx context.Context, arg database.ResolveReportsForUserParams) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var n int64
+	for i, r := range db.reports {
+		if r.ReportedUserID == arg.ReportedUserID && r.Status == reportStatusOpen {
+			db.reports[i].Status = reportStatusActioned
+			db.reports[i].ResolvedBy = arg.ResolvedBy
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (db *fakeDB) CreateModerationAction(ctx context.Context, arg database.CreateModerationActionParams) (database.ModerationAction, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.modActions = append(db.modActions, arg)
+	return database.ModerationAction{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now().UTC(),
+		ModeratorID: arg.ModeratorID,
+		Action:      arg.Action,
+		ChirpID:     arg.ChirpID,
+		UserID:      arg.UserID,
+		ReportID:    arg.ReportID,
+		Note:        arg.Note,
+	}, nil
+}
+
+// GetChirp only models the author seeing their own chirps and everyone
+// seeing public and unlisted ones; chirp_visible_to is SQL.
+func (db *fakeDB) addFollow(followerID, followeeID uuid.UUID, status string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.follows = append(db.follows, database.Follow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+		CreatedAt:  time.Now().UTC(),
+		Status:     status,
+	})
+}
+
+func (db *fakeDB) addMention(chirpID, userID uuid.UUID) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.mentions[chirpID] = append(db.mentions[chirpID], userID)
+}
+
+// visibleTo mirrors the chirp_visible_to SQL function. The caller holds db.mu.
+func (db *fakeDB) visibleTo(chirp database.Chirp, viewer uuid.NullUUID) bool {
+	if viewer.Valid && viewer.UUID == chirp.UserID {
+		return true
+	}
+	author := db.users[chirp.UserID]
+	_, restricted := restrictionFor(author, time.Now().UTC())
+	if chirp.HiddenAt.Valid || (restricted && author.ChirpsHidden) {
+		return false
+	}
+	follows := viewer.Valid && slices.ContainsFunc(db.follows, func(f database.Follow) bool {
+		return f.FollowerID == viewer.UUID && f.FolloweeID == chirp.UserID && f.Status == followStatusAccepted
+	})
+	switch chirp.Visibility {
+	case chirpVisibilityPublic, chirpVisibilityUnlisted:
+		return !author.Protected || follows
+	case chirpVisibilityFollowers:
+		return follows
+	case chirpVisibilityMentioned:
+		return viewer.Valid && slices.Contains(db.mentions[chirp.ID], viewer.UUID)
+	}
+	return false
+}
+
+func (db *fakeDB) GetChirp(ctx context.Context, arg database.GetChirpParams) (database.Chirp, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	chirp, ok := db.chirps[arg.ID]
+	if !ok || chirp.DeletedAt.Valid || !db.visibleTo(chirp, arg.ViewerID) {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return chirp, nil
+}
+
+// listChirps is GetAllChirps and GetChirpsByAuthor without mutes.
+func (db *fakeDB) listChirps(authorID uuid.UUID, viewer uuid.NullUUID) []database.Chirp {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var chirps []database.Chirp
+	for _, chirp := range db.chirps {
+		if authorID != uuid.Nil && chirp.UserID != authorID {
+			continue
+		}
+		own := viewer.Valid && viewer.UUID == chirp.UserID
+		if chirp.DeletedAt.Valid || (chirp.Visibility == chirpVisibilityUnlisted && !own) || !db.visibleTo(chirp, viewer) {
+			continue
+		}
+		chirps = append(chirps, chirp)
+	}
+	slices.SortFunc(chirps, func(a, b database.Chirp) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return chirps
+}
+
+func (db *fakeDB) GetAllChirps(ctx context.Context, viewerID uuid.NullUUID) ([]database.Chirp, error) {
+	return db.listChirps(uuid.Nil, viewerID), nil
+}
+
+func (db *fakeDB) GetChirpsByAuthor(ctx context.Context, arg database.GetChirpsByAuthorParams) ([]database.Chirp, error) {
+	return db.listChirps(arg.UserID, arg.ViewerID), nil
+}
+
+func (db *fakeDB) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	chirp := db.addChirp(arg.UserID, arg.Body, arg.Visibility)
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	chirp.ParentID = arg.ParentID
+	db.chirps[chirp.ID] = chirp
+	return chirp, nil
+}
+
+func (db *fakeDB) UpdateChirp(ctx context.Context, arg database.UpdateChirpParams) (database.Chirp, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	chirp, ok := db.chirps[arg.ID]
+	if !ok {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	chirp.Body = arg.Body
+	chirp.UpdatedAt = time.Now().UTC()
+	db.chirps[arg.ID] = chirp
+	return chirp, nil
+}
+
+// CreateReport returns sql.ErrNoRows when the reporter has already reported
+// the chirp, like its ON CONFLICT DO NOTHING.
+func (db *fakeDB) CreateReport(ctx context.Context, arg database.CreateReportParams) (database.Report, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, r := range db.reports {
+		if arg.ReporterID.Valid && r.ChirpID == arg.ChirpID && r.ReporterID == arg.ReporterID {
+			return database.Report{}, sql.ErrNoRows
+		}
+	}
+	now := time.Now().UTC()
+	report := database.Report{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		ChirpID:        arg.ChirpID,
+		ReportedUserID: arg.ReportedUserID,
+		ReporterID:     arg.ReporterID,
+		Reason:         arg.Reason,
+		Details:        arg.Details,
+		Status:         reportStatusOpen,
+	}
+	db.reports = append(db.reports, report)
+	return report, nil
+}
+
+func isOpenFilterReport(r database.Report, chirpID uuid.UUID) bool {
+	return r.ChirpID.Valid && r.ChirpID.UUID == chirpID && !r.ReporterID.Valid && r.Reason == "filtered" && r.Status == reportStatusOpen
+}
+
+// FlagChirp keeps one open filter report per chirp, like the partial unique
+// index its ON CONFLICT targets.
+func (db *fakeDB) FlagChirp(ctx context.Context, arg database.FlagChirpParams) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	chirp, ok := db.chirps[arg.ChirpID]
+	if !ok {
+		return nil
+	}
+	details := strings.Join(arg.Words, ", ")
+	for i, r := range db.reports {
+		if isOpenFilterReport(r, arg.ChirpID) {
+			db.reports[i].Details = details
+			return nil
+		}
+	}
+	now := time.Now().UTC()
+	db.reports = append(db.reports, database.Report{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		ChirpID:        uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		ReportedUserID: chirp.UserID,
+		Reason:         "filtered",
+		Details:        details,
+		Status:         reportStatusOpen,
+	})
+	return nil
+}
+
+func (db *fakeDB) DismissFilterReports(ctx context.Context, chirpID uuid.NullUUID) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var n int64
+	for i, r := range db.reports {
+		if isOpenFilterReport(r, chirpID.UUID) {
+			db.reports[i].Status = reportStatusDismissed
+			n++
+		}
+	}
+	return n, nil
+}
+
+// newTestConfig returns a config whose handlers run against db.
+func newTestConfig(t *testing.T, db *fakeDB) *apiConfig {
+	t.Helper()
+	return &apiConfig{
+		db:                  db,
+		withTx:              db.withTx,
+		jwtSecret:           testJWTSecret,
+		polkaKey:            "polka-key",
+		polkaAllowLegacyKey: true,
+		hub:                 realtime.NewHub(),
+		events:              realtime.NewHub(),
+		notifications:       make(chan notificationJob, notificationQueueSize),
+		contentFilter:       contentfilter.New(nil),
+		metrics:             metrics.New(nil),
+		streamsClosed:       make(chan struct{}),
+	}
+}
+
+// newTestRequest builds a request with a JSON body and, when userID isn't
+// nil, an access token for that user.
+func newTestRequest(t *testing.T, method, target string, userID uuid.UUID, body any) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, target, &buf)
+	if userID != uuid.Nil {
+		token, err := auth.MakeJWT(userID, testJWTSecret, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
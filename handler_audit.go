@@ -0,0 +1,96 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type AuditEvent struct {
+	ID        uuid.UUID       `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Type      string          `json:"type"`
+	ActorID   *uuid.UUID      `json:"actor_id"`
+	TargetID  *uuid.UUID      `json:"target_id"`
+	IP        string          `json:"ip"`
+	UserAgent string          `json:"user_agent"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// handlerAuditList serves the audit log to admins, newest first. It can be
+// filtered by type, actor_id, target_id and a since/until range of RFC 3339
+// timestamps.
+func (cfg *apiConfig) handlerAuditList(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireRole(w, req, roleAdmin); !ok {
+		return
+	}
+
+	limit, offset, err := paginationFromRequest(req)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	query := req.URL.Query()
+	params := database.ListAuditEventsParams{
+		PageLimit:  limit,
+		PageOffset: offset,
+	}
+	if v := query.Get("type"); v != "" {
+		params.EventType = sql.NullString{String: v, Valid: true}
+	}
+	for name, dst := range map[string]*uuid.NullUUID{"actor_id": &params.ActorID, "target_id": &params.TargetID} {
+		if v := query.Get(name); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				respondWithError(w, req, http.StatusBadRequest, "invalid "+name, err)
+				return
+			}
+			*dst = uuid.NullUUID{UUID: id, Valid: true}
+		}
+	}
+	for name, dst := range map[string]*sql.NullTime{"since": &params.Since, "until": &params.Until} {
+		if v := query.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				respondWithError(w, req, http.StatusBadRequest, name+" must be an RFC 3339 timestamp", err)
+				return
+			}
+			*dst = sql.NullTime{Time: t.UTC(), Valid: true}
+		}
+	}
+
+	events, err := cfg.db.ListAuditEvents(req.Context(), params)
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't retrieve audit events", err)
+		return
+	}
+
+	resp := make([]AuditEvent, 0, len(events))
+	for _, e := range events {
+		resp = append(resp, mapAuditEvent(e))
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func mapAuditEvent(e database.AuditEvent) AuditEvent {
+	event := AuditEvent{
+		ID:        e.ID,
+		CreatedAt: e.CreatedAt,
+		Type:      e.EventType,
+		IP:        e.Ip,
+		UserAgent: e.UserAgent,
+		Payload:   e.Payload,
+	}
+	if e.ActorID.Valid {
+		event.ActorID = &e.ActorID.UUID
+	}
+	if e.TargetID.Valid {
+		event.TargetID = &e.TargetID.UUID
+	}
+	return event
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// handlerBlocksCreate blocks a user. Any follow relationship between the two
+// users is removed in either direction.
+func (cfg *apiConfig) handlerBlocksCreate(w http.ResponseWriter, req *http.Request) {
+	userID, target, ok := cfg.userTarget(w, req)
+	if !ok {
+		return
+	}
+	if target.ID == userID {
+		respondWithError(w, req, http.StatusBadRequest, "you can't block yourself", nil)
+		return
+	}
+
+	err := cfg.withTx(req.Context(), func(q database.Querier) error {
+		_, err := q.BlockUser(req.Context(), database.BlockUserParams{
+			BlockerID: userID,
+			BlockedID: target.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.UnfollowUser(req.Context(), database.UnfollowUserParams{
+			FollowerID: target.ID,
+			FolloweeID: userID,
+		})
+		if err != nil {
+			return err
+		}
+		return q.UnfollowUser(req.Context(), database.UnfollowUserParams{
+			FollowerID: userID,
+			FolloweeID: target.ID,
+		})
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to block user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerBlocksDelete(w http.ResponseWriter, req *http.Request) {
+	userID, target, ok := cfg.userTarget(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.UnblockUser(req.Context(), database.UnblockUserParams{
+		BlockerID: userID,
+		BlockedID: target.ID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to unblock user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerMutesCreate(w http.ResponseWriter, req *http.Request) {
+	userID, target, ok := cfg.userTarget(w, req)
+	if !ok {
+		return
+	}
+	if target.ID == userID {
+		respondWithError(w, req, http.StatusBadRequest, "you can't mute yourself", nil)
+		return
+	}
+
+	_, err := cfg.db.MuteUser(req.Context(), database.MuteUserParams{
+		MuterID: userID,
+		MutedID: target.ID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to mute user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerMutesDelete(w http.ResponseWriter, req *http.Request) {
+	userID, target, ok := cfg.userTarget(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.UnmuteUser(req.Context(), database.UnmuteUserParams{
+		MuterID: userID,
+		MutedID: target.ID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to unmute user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requireNotBlocked writes a 403 response when blockerID has blocked userID.
+func (cfg *apiConfig) requireNotBlocked(w http.ResponseWriter, req *http.Request, blockerID, userID uuid.UUID) bool {
+	blocked, err := cfg.db.IsBlocked(req.Context(), database.IsBlockedParams{
+		BlockerID: blockerID,
+		BlockedID: userID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't check block status", err)
+		return false
+	}
+	if blocked {
+		respondWithError(w, req, http.StatusForbidden, "you have been blocked by this user", nil)
+		return false
+	}
+	return true
+}
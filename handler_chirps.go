@@ -1,67 +1,149 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"slices"
-	"strings"
 	"time"
 
-	"github.com/CybrRonin/Chirpy/internal/auth"
+	"github.com/CybrRonin/Chirpy/internal/contentfilter"
 	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/entitlements"
+	"github.com/CybrRonin/Chirpy/internal/realtime"
+	"github.com/CybrRonin/Chirpy/internal/webhooks"
 	"github.com/google/uuid"
 )
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserID    uuid.UUID `json:"user_id"`
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Body       string     `json:"body"`
+	UserID     uuid.UUID  `json:"user_id"`
+	ParentID   *uuid.UUID `json:"parent_id,omitempty"`
+	Visibility string     `json:"visibility"`
+	// Hidden is only ever true for the author; hidden chirps aren't shown to anyone else.
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+const (
+	chirpVisibilityPublic    = "public"
+	chirpVisibilityUnlisted  = "unlisted"
+	chirpVisibilityFollowers = "followers"
+	chirpVisibilityMentioned = "mentioned"
+)
+
+var chirpVisibilities = []string{
+	chirpVisibilityPublic,
+	chirpVisibilityUnlisted,
+	chirpVisibilityFollowers,
+	chirpVisibilityMentioned,
 }
 
 func (cfg *apiConfig) handlerChirpsCreate(w http.ResponseWriter, req *http.Request) {
 	type parameters struct {
 		Body string `json:"body"`
 		//UserID uuid.UUID `json:"user_id"`
+		ParentID *uuid.UUID `json:"parent_id"`
+		// Visibility defaults to public.
+		Visibility string `json:"visibility"`
 	}
 
-	token, err := auth.GetBearerToken(req.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+	uID, ok := cfg.requireUser(w, req)
+	if !ok {
 		return
 	}
 
-	uID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	reqParams := parameters{}
+	err := decodeJSON(req.Body, &reqParams)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		respondWithError(w, req, http.StatusInternalServerError, "failed to decode chirp parameters", err)
 		return
 	}
 
-	reqParams := parameters{}
-	err = decodeJSON(req.Body, &reqParams)
+	author, err := cfg.entitlementsFor(req.Context(), uID)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to decode chirp parameters", err)
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't look up author", err)
 		return
 	}
 
-	cleaned, err := validateChirp(reqParams.Body)
+	filtered, err := cfg.validateChirp(reqParams.Body, author)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid chirp: ", err)
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if reqParams.Visibility == "" {
+		reqParams.Visibility = chirpVisibilityPublic
+	}
+	if !slices.Contains(chirpVisibilities, reqParams.Visibility) {
+		respondWithError(w, req, http.StatusBadRequest, "visibility must be one of public, unlisted, followers or mentioned", nil)
 		return
 	}
 
 	params := database.CreateChirpParams{
-		Body:   cleaned,
-		UserID: uID,
+		Body:       filtered.Text,
+		UserID:     uID,
+		Visibility: reqParams.Visibility,
+	}
+	var parent database.Chirp
+	if reqParams.ParentID != nil {
+		parent, err = cfg.db.GetChirp(req.Context(), database.GetChirpParams{
+			ID:       *reqParams.ParentID,
+			ViewerID: uuid.NullUUID{UUID: uID, Valid: true},
+		})
+		if err != nil {
+			respondWithError(w, req, http.StatusNotFound, "couldn't find the chirp being replied to", err)
+			return
+		}
+		if !cfg.requireNotBlocked(w, req, parent.UserID, uID) {
+			return
+		}
+		params.ParentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
 	}
-	ch, err := cfg.db.CreateChirp(req.Context(), params)
+
+	var chirp Chirp
+	var mentioned []uuid.UUID
+	err = cfg.withTx(req.Context(), func(q database.Querier) error {
+		ch, err := q.CreateChirp(req.Context(), params)
+		if err != nil {
+			return err
+		}
+		chirp = mapChirp(ch)
+
+		err = flagChirp(req.Context(), q, ch.ID, filtered)
+		if err != nil {
+			return err
+		}
+		mentioned, err = createMentions(req.Context(), q, ch)
+		if err != nil {
+			return err
+		}
+		return cfg.enqueueChirpWebhook(req.Context(), q, webhooks.EventChirpCreated, chirp)
+	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to create chirp", err)
+		respondWithError(w, req, http.StatusInternalServerError, "failed to create chirp", err)
 		return
 	}
 
-	chirp := mapChirp(ch)
+	cfg.metrics.ObserveChirpCreated()
+	cfg.publishChirpEvent(req.Context(), realtime.EventChirpCreated, chirp)
+	if params.ParentID.Valid {
+		cfg.notify(notificationJob{
+			Type:       notificationTypeReply,
+			ActorID:    uID,
+			Recipients: []uuid.UUID{parent.UserID},
+			ChirpID:    chirp.ID,
+		})
+	}
+	cfg.notify(notificationJob{
+		Type:       notificationTypeMention,
+		ActorID:    uID,
+		Recipients: mentioned,
+		ChirpID:    chirp.ID,
+	})
 	respondWithJSON(w, http.StatusCreated, chirp)
 }
 
@@ -77,17 +159,30 @@ func authorIDFromRequest(r *http.Request) (uuid.UUID, error) {
 	return authorID, nil
 }
 
+// viewerFromRequest resolves the caller from an optional access token.
+// Anonymous callers get a null viewer, which only sees public chirps.
+func (cfg *apiConfig) viewerFromRequest(req *http.Request) uuid.NullUUID {
+	userID, ok := cfg.authenticatedUserID(req)
+	return uuid.NullUUID{UUID: userID, Valid: ok}
+}
+
 func (cfg *apiConfig) handlerChirpsGetAll(w http.ResponseWriter, req *http.Request) {
 	authorID, err := authorIDFromRequest(req)
 	var resp []database.Chirp
 
+	// Signed-in callers don't see chirps from users they've muted.
+	viewer := cfg.viewerFromRequest(req)
+
 	if authorID != uuid.Nil {
-		resp, err = cfg.db.GetChirpsByAuthor(req.Context(), authorID)
+		resp, err = cfg.db.GetChirpsByAuthor(req.Context(), database.GetChirpsByAuthorParams{
+			UserID:   authorID,
+			ViewerID: viewer,
+		})
 	} else {
-		resp, err = cfg.db.GetAllChirps(req.Context())
+		resp, err = cfg.db.GetAllChirps(req.Context(), viewer)
 	}
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to retrieve chirps", err)
+		respondWithError(w, req, http.StatusInternalServerError, "failed to retrieve chirps", err)
 		return
 	}
 
@@ -102,13 +197,16 @@ func (cfg *apiConfig) handlerChirpsGetAll(w http.ResponseWriter, req *http.Reque
 func (cfg *apiConfig) handlerChirpsGet(w http.ResponseWriter, req *http.Request) {
 	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid chirp ID", err)
+		respondWithError(w, req, http.StatusBadRequest, "invalid chirp ID", err)
 		return
 	}
 
-	dbChirp, err := cfg.db.GetChirp(req.Context(), chirpID)
+	dbChirp, err := cfg.db.GetChirp(req.Context(), database.GetChirpParams{
+		ID:       chirpID,
+		ViewerID: cfg.viewerFromRequest(req),
+	})
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "couldn't retrieve chirp", err)
+		respondWithError(w, req, http.StatusNotFound, "couldn't retrieve chirp", err)
 		return
 	}
 
@@ -116,73 +214,173 @@ func (cfg *apiConfig) handlerChirpsGet(w http.ResponseWriter, req *http.Request)
 }
 
 func (cfg *apiConfig) handlerChirpsDelete(w http.ResponseWriter, req *http.Request) {
-	token, err := auth.GetBearerToken(req.Header)
+	userID, ok := cfg.requireUser(w, req)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't find JWT", err)
+		respondWithError(w, req, http.StatusBadRequest, "invalid chirp ID", err)
 		return
 	}
 
-	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	chirp, err := cfg.db.GetChirp(req.Context(), database.GetChirpParams{
+		ID:       chirpID,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusNotFound, "unable to retrieve chirp", err)
+		return
+	}
+
+	if userID != chirp.UserID {
+		respondWithError(w, req, http.StatusForbidden, "not oauthorized to delete chirp", err)
+		return
+	}
+
+	err = cfg.withTx(req.Context(), func(q database.Querier) error {
+		err := q.DeleteChirp(req.Context(), database.DeleteChirpParams{
+			DeletedBy: uuid.NullUUID{UUID: userID, Valid: true},
+			ID:        chirpID,
+		})
+		if err != nil {
+			return err
+		}
+		return cfg.enqueueChirpWebhook(req.Context(), q, webhooks.EventChirpDeleted, mapChirp(chirp))
+	})
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		respondWithError(w, req, http.StatusInternalServerError, "failed to delete chirp", err)
+		return
+	}
+
+	cfg.publishChirpEvent(req.Context(), realtime.EventChirpDeleted, mapChirp(chirp))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerChirpsUpdate(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	userID, ok := cfg.requireUser(w, req)
+	if !ok {
 		return
 	}
 
 	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid chirp ID", err)
+		respondWithError(w, req, http.StatusBadRequest, "invalid chirp ID", err)
 		return
 	}
 
-	chirp, err := cfg.db.GetChirp(req.Context(), chirpID)
+	chirp, err := cfg.db.GetChirp(req.Context(), database.GetChirpParams{
+		ID:       chirpID,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, "unable to retrieve chirp", err)
+		respondWithError(w, req, http.StatusNotFound, "unable to retrieve chirp", err)
 		return
 	}
 
 	if userID != chirp.UserID {
-		respondWithError(w, http.StatusForbidden, "not oauthorized to delete chirp", err)
+		respondWithError(w, req, http.StatusForbidden, "not authorized to edit chirp", nil)
 		return
 	}
 
-	err = cfg.db.DeleteChirp(req.Context(), chirpID)
+	author, err := cfg.entitlementsFor(req.Context(), userID)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to delete chirp", err)
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't look up author", err)
+		return
+	}
+	if !author.CanEditChirps {
+		respondWithError(w, req, http.StatusForbidden, "editing chirps requires Chirpy Red", nil)
+		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	reqParams := parameters{}
+	err = decodeJSON(req.Body, &reqParams)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "failed to decode chirp parameters", err)
+		return
+	}
+
+	filtered, err := cfg.validateChirp(reqParams.Body, author)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	var updated database.Chirp
+	err = cfg.withTx(req.Context(), func(q database.Querier) error {
+		updated, err = q.UpdateChirp(req.Context(), database.UpdateChirpParams{
+			ID:   chirpID,
+			Body: filtered.Text,
+		})
+		if err != nil {
+			return err
+		}
+		if len(filtered.Flagged) == 0 {
+			// The edit removed whatever the filter flagged before.
+			_, err = q.DismissFilterReports(req.Context(), uuid.NullUUID{UUID: chirpID, Valid: true})
+		} else {
+			err = flagChirp(req.Context(), q, chirpID, filtered)
+		}
+		if err != nil {
+			return err
+		}
+		return cfg.enqueueChirpWebhook(req.Context(), q, webhooks.EventChirpUpdated, mapChirp(updated))
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to update chirp", err)
+		return
+	}
+
+	resp := mapChirp(updated)
+	cfg.publishChirpEvent(req.Context(), realtime.EventChirpUpdated, resp)
+	respondWithJSON(w, http.StatusOK, resp)
 }
 
 func mapChirp(ch database.Chirp) Chirp {
-	return Chirp{
-		ID:        ch.ID,
-		CreatedAt: ch.CreatedAt,
-		UpdatedAt: ch.UpdatedAt,
-		Body:      ch.Body,
-		UserID:    ch.UserID,
+	chirp := Chirp{
+		ID:         ch.ID,
+		CreatedAt:  ch.CreatedAt,
+		UpdatedAt:  ch.UpdatedAt,
+		Body:       ch.Body,
+		UserID:     ch.UserID,
+		Visibility: ch.Visibility,
+		Hidden:     ch.HiddenAt.Valid,
+	}
+	if ch.ParentID.Valid {
+		chirp.ParentID = &ch.ParentID.UUID
 	}
+	return chirp
 }
 
-func validateChirp(body string) (string, error) {
-	const maxChirpLength = 140
-	if len(body) > maxChirpLength {
-		return "", errors.New("Chirp is too long")
+// validateChirp checks a chirp body against the author's entitlements and the
+// content filter. The returned result holds the masked body and any words
+// that should be flagged for review.
+func (cfg *apiConfig) validateChirp(body string, author entitlements.Entitlements) (contentfilter.Result, error) {
+	if contentfilter.Length(body) > author.MaxChirpLength {
+		return contentfilter.Result{}, fmt.Errorf("Chirp is too long: the limit is %d characters", author.MaxChirpLength)
 	}
 
-	cleaned := cleanPost(body)
-	return cleaned, nil
+	res := cfg.contentFilter.Check(body)
+	if res.Rejected {
+		return contentfilter.Result{}, errors.New("Chirp contains a word that isn't allowed")
+	}
+	return res, nil
 }
 
-func cleanPost(msg string) string {
-	var censoredWords = []string{"kerfuffle", "sharbert", "fornax"}
-	const censored = "****"
-	msgWords := strings.Split(msg, " ")
-
-	for i, word := range msgWords {
-		if slices.Contains(censoredWords, strings.ToLower(word)) {
-			msgWords[i] = censored
-		}
+// flagChirp queues a chirp for moderator review when the content filter
+// flagged any of its words.
+func flagChirp(ctx context.Context, q database.Querier, chirpID uuid.UUID, res contentfilter.Result) error {
+	if len(res.Flagged) == 0 {
+		return nil
 	}
-
-	return strings.Join(msgWords, " ")
+	return q.FlagChirp(ctx, database.FlagChirpParams{
+		ChirpID: chirpID,
+		Words:   res.Flagged,
+	})
 }
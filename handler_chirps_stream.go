@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/realtime"
+	"github.com/google/uuid"
+)
+
+const sseKeepAliveInterval = 15 * time.Second
+
+// handlerChirpsStream pushes chirp events to the client as Server-Sent Events.
+// Clients that reconnect with Last-Event-ID get the events they missed, as
+// long as they're still in the hub's replay buffer.
+func (cfg *apiConfig) handlerChirpsStream(w http.ResponseWriter, req *http.Request) {
+	authorID, err := authorIDFromRequest(req)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "invalid author ID", err)
+		return
+	}
+
+	var lastEventID uint64
+	if v := req.Header.Get("Last-Event-ID"); v != "" {
+		lastEventID, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			respondWithError(w, req, http.StatusBadRequest, "invalid Last-Event-ID", err)
+			return
+		}
+	}
+
+	filter := func(e realtime.Event) bool {
+		return e.HasTopic(realtime.TopicFeed) && (authorID == uuid.Nil || e.AuthorID == authorID)
+	}
+	sub, backlog := cfg.hub.Subscribe(lastEventID, filter)
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	// The server's read and write timeouts are sized for ordinary requests;
+	// a stream only ends when the client leaves or the server shuts down.
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range backlog {
+		if err := writeSSE(w, e); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-cfg.streamsClosed:
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				// We fell too far behind; the client will reconnect and resume.
+				return
+			}
+			err = writeSSE(w, e)
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, e realtime.Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+	return err
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/contentfilter"
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/entitlements"
+	"github.com/CybrRonin/Chirpy/internal/realtime"
+	"github.com/CybrRonin/Chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+// addRedUser adds a user whose plan lets them edit chirps.
+func addRedUser(db *fakeDB) database.User {
+	user := db.addUser(roleUser)
+	db.subscriptions[user.ID] = database.Subscription{
+		ID:               uuid.New(),
+		UserID:           user.ID,
+		Plan:             string(entitlements.PlanRed),
+		Status:           subscriptionStatusActive,
+		CurrentPeriodEnd: time.Now().Add(time.Hour),
+	}
+	return user
+}
+
+func editChirp(t *testing.T, cfg *apiConfig, userID, chirpID uuid.UUID, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := newTestRequest(t, http.MethodPut, "/api/chirps/"+chirpID.String(), userID, map[string]string{"body": body})
+	req.SetPathValue("chirpID", chirpID.String())
+	rec := httptest.NewRecorder()
+	cfg.handlerChirpsUpdate(rec, req)
+	return rec
+}
+
+func TestChirpEditsKeepOneFilterReport(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+	cfg.contentFilter = contentfilter.New([]contentfilter.Rule{
+		{Word: "spoiler", Action: contentfilter.ActionFlag},
+		{Word: "leak", Action: contentfilter.ActionFlag},
+	})
+	author := addRedUser(db)
+	chirp := db.addChirp(author.ID, "hello", chirpVisibilityPublic)
+
+	for _, body := range []string{"a spoiler", "a spoiler and a leak"} {
+		if rec := editChirp(t, cfg, author.ID, chirp.ID, body); rec.Code != http.StatusOK {
+			t.Fatalf("edit %q: status = %d, want %d: %s", body, rec.Code, http.StatusOK, rec.Body)
+		}
+	}
+	if len(db.reports) != 1 {
+		t.Fatalf("got %d reports, want 1", len(db.reports))
+	}
+	if got, want := db.reports[0].Details, "spoiler, leak"; got != want {
+		t.Errorf("report details = %q, want %q", got, want)
+	}
+
+	if rec := editChirp(t, cfg, author.ID, chirp.ID, "all clean now"); rec.Code != http.StatusOK {
+		t.Fatalf("clean edit: status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if got := db.reports[0].Status; got != reportStatusDismissed {
+		t.Errorf("report status after a clean edit = %q, want %q", got, reportStatusDismissed)
+	}
+}
+
+func TestChirpsCreateOnlyQueuesWebhooksForPublicChirps(t *testing.T) {
+	tests := []struct {
+		visibility string
+		protected  bool
+		want       int
+	}{
+		{chirpVisibilityPublic, false, 1},
+		{chirpVisibilityPublic, true, 0},
+		{chirpVisibilityUnlisted, false, 0},
+		{chirpVisibilityFollowers, false, 0},
+		{chirpVisibilityMentioned, false, 0},
+	}
+
+	for _, tt := range tests {
+		db := newFakeDB()
+		cfg := newTestConfig(t, db)
+		author := db.addUser(roleUser)
+		author.Protected = tt.protected
+		db.users[author.ID] = author
+
+		req := newTestRequest(t, http.MethodPost, "/api/chirps", author.ID, map[string]string{
+			"body":       "hello",
+			"visibility": tt.visibility,
+		})
+		rec := httptest.NewRecorder()
+		cfg.handlerChirpsCreate(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("%s chirp: status = %d, want %d: %s", tt.visibility, rec.Code, http.StatusCreated, rec.Body)
+		}
+		if len(db.outbox) != tt.want {
+			t.Errorf("%s chirp (protected author: %v): got %d webhook events, want %d", tt.visibility, tt.protected, len(db.outbox), tt.want)
+		}
+	}
+}
+
+func TestChirpEditPublishesUpdate(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+	author := addRedUser(db)
+	chirp := db.addChirp(author.ID, "hello", chirpVisibilityPublic)
+
+	cfg.events = cfg.hub
+	sub, _ := cfg.hub.Subscribe(0, nil)
+	defer sub.Close()
+
+	if rec := editChirp(t, cfg, author.ID, chirp.ID, "hello again"); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	select {
+	case e := <-sub.Events():
+		if e.Type != realtime.EventChirpUpdated {
+			t.Errorf("event type = %q, want %q", e.Type, realtime.EventChirpUpdated)
+		}
+	default:
+		t.Error("no realtime event was published for the edit")
+	}
+	if len(db.outbox) != 1 || db.outbox[0].EventType != webhooks.EventChirpUpdated {
+		t.Errorf("outbox = %+v, want one %s event", db.outbox, webhooks.EventChirpUpdated)
+	}
+}
+
+func TestChirpEditExplainsRejection(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+	author := addRedUser(db)
+	chirp := db.addChirp(author.ID, "hello", chirpVisibilityPublic)
+
+	rec := editChirp(t, cfg, author.ID, chirp.ID, strings.Repeat("a", 100000))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), "Chirp is too long") {
+		t.Errorf("response %s doesn't say why the chirp was rejected", rec.Body)
+	}
+}
+
+func getChirp(t *testing.T, cfg *apiConfig, viewerID, chirpID uuid.UUID) int {
+	t.Helper()
+	req := newTestRequest(t, http.MethodGet, "/api/chirps/"+chirpID.String(), viewerID, nil)
+	req.SetPathValue("chirpID", chirpID.String())
+	rec := httptest.NewRecorder()
+	cfg.handlerChirpsGet(rec, req)
+	return rec.Code
+}
+
+func TestChirpsGetVisibility(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+
+	author := db.addUser(roleUser)
+	follower := db.addUser(roleUser)
+	mentioned := db.addUser(roleUser)
+	stranger := db.addUser(roleUser)
+	db.addFollow(follower.ID, author.ID, followStatusAccepted)
+
+	chirps := map[string]database.Chirp{}
+	for _, visibility := range chirpVisibilities {
+		chirps[visibility] = db.addChirp(author.ID, "hello", visibility)
+	}
+	db.addMention(chirps[chirpVisibilityMentioned].ID, mentioned.ID)
+
+	viewers := []struct {
+		name string
+		id   uuid.UUID
+		sees []string
+	}{
+		{"author", author.ID, chirpVisibilities},
+		{"follower", follower.ID, []string{chirpVisibilityPublic, chirpVisibilityUnlisted, chirpVisibilityFollowers}},
+		{"mentioned user", mentioned.ID, []string{chirpVisibilityPublic, chirpVisibilityUnlisted, chirpVisibilityMentioned}},
+		{"stranger", stranger.ID, []string{chirpVisibilityPublic, chirpVisibilityUnlisted}},
+		{"anonymous", uuid.Nil, []string{chirpVisibilityPublic, chirpVisibilityUnlisted}},
+	}
+
+	for _, viewer := range viewers {
+		for _, visibility := range chirpVisibilities {
+			t.Run(viewer.name+"/"+visibility, func(t *testing.T) {
+				want := http.StatusNotFound
+				if slices.Contains(viewer.sees, visibility) {
+					want = http.StatusOK
+				}
+				if got := getChirp(t, cfg, viewer.id, chirps[visibility].ID); got != want {
+					t.Errorf("status = %d, want %d", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestChirpsGetProtectedAuthor(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+
+	author := db.addUser(roleUser)
+	author.Protected = true
+	db.users[author.ID] = author
+	follower := db.addUser(roleUser)
+	pending := db.addUser(roleUser)
+	db.addFollow(follower.ID, author.ID, followStatusAccepted)
+	db.addFollow(pending.ID, author.ID, followStatusPending)
+	chirp := db.addChirp(author.ID, "hello", chirpVisibilityPublic)
+
+	tests := []struct {
+		name     string
+		viewerID uuid.UUID
+		want     int
+	}{
+		{"Author", author.ID, http.StatusOK},
+		{"Approved follower", follower.ID, http.StatusOK},
+		{"Pending follower", pending.ID, http.StatusNotFound},
+		{"Anonymous", uuid.Nil, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getChirp(t, cfg, tt.viewerID, chirp.ID); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChirpsGetAllLeavesOutUnlisted(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+
+	author := db.addUser(roleUser)
+	follower := db.addUser(roleUser)
+	db.addFollow(follower.ID, author.ID, followStatusAccepted)
+	for _, visibility := range chirpVisibilities {
+		db.addChirp(author.ID, visibility, visibility)
+	}
+
+	tests := []struct {
+		name     string
+		viewerID uuid.UUID
+		want     []string
+	}{
+		{"Author", author.ID, chirpVisibilities},
+		{"Follower", follower.ID, []string{chirpVisibilityPublic, chirpVisibilityFollowers}},
+		{"Anonymous", uuid.Nil, []string{chirpVisibilityPublic}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			cfg.handlerChirpsGetAll(rec, newTestRequest(t, http.MethodGet, "/api/chirps", tt.viewerID, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+			}
+			var chirps []Chirp
+			if err := json.NewDecoder(rec.Body).Decode(&chirps); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, chirp := range chirps {
+				got = append(got, chirp.Visibility)
+			}
+			slices.Sort(got)
+			want := slices.Sorted(slices.Values(tt.want))
+			if !slices.Equal(got, want) {
+				t.Errorf("listed visibilities = %v, want %v", got, want)
+			}
+		})
+	}
+}
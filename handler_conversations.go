@@ -0,0 +1,456 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/contentfilter"
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/realtime"
+	"github.com/google/uuid"
+)
+
+const (
+	maxMessageLength            = 1000
+	maxConversationParticipants = 50
+
+	eventMessageCreated = "message.created"
+)
+
+var errConversationBlocked = errors.New("conversation is blocked")
+
+type Conversation struct {
+	ID           uuid.UUID                 `json:"id"`
+	CreatedAt    time.Time                 `json:"created_at"`
+	UpdatedAt    time.Time                 `json:"updated_at"`
+	Participants []ConversationParticipant `json:"participants"`
+	UnreadCount  int64                     `json:"unread_count"`
+}
+
+// ConversationParticipant doubles as a read receipt: LastReadAt is when the
+// participant last marked the conversation as read.
+type ConversationParticipant struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	LastReadAt *time.Time `json:"last_read_at"`
+	Blocked    bool       `json:"blocked"`
+}
+
+type Message struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Body           string    `json:"body"`
+}
+
+// handlerConversationsCreate starts a conversation with the given users. A
+// one-to-one conversation that already exists is reused rather than duplicated.
+func (cfg *apiConfig) handlerConversationsCreate(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		ParticipantIDs []uuid.UUID `json:"participant_ids"`
+		Body           string      `json:"body"`
+	}
+
+	userID, ok := cfg.requireUser(w, req)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(req.Body, &params)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "couldn't decode parameters", err)
+		return
+	}
+
+	others := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{userID: true}
+	for _, id := range params.ParticipantIDs {
+		if !seen[id] {
+			seen[id] = true
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 || len(others) >= maxConversationParticipants {
+		respondWithError(w, req, http.StatusBadRequest, "a conversation needs between 2 and 50 participants", nil)
+		return
+	}
+	for _, id := range others {
+		_, err := cfg.db.GetUserByID(req.Context(), id)
+		if err != nil {
+			respondWithError(w, req, http.StatusBadRequest, "couldn't find participant "+id.String(), err)
+			return
+		}
+		if !cfg.requireNotBlocked(w, req, id, userID) {
+			return
+		}
+	}
+
+	var body string
+	if params.Body != "" {
+		body, err = cfg.validateMessage(params.Body)
+		if err != nil {
+			respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+
+	var conversation database.Conversation
+	var message *Message
+	created := false
+	err = cfg.withTx(req.Context(), func(q database.Querier) error {
+		if len(others) == 1 {
+			conversation, err = q.FindDirectConversation(req.Context(), database.FindDirectConversationParams{
+				UserID:      userID,
+				OtherUserID: others[0],
+			})
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+
+		if conversation.ID == uuid.Nil {
+			conversation, err = q.CreateConversation(req.Context())
+			if err != nil {
+				return err
+			}
+			created = true
+			for _, id := range append([]uuid.UUID{userID}, others...) {
+				err := q.AddConversationParticipant(req.Context(), database.AddConversationParticipantParams{
+					ConversationID: conversation.ID,
+					UserID:         id,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if body == "" {
+			return nil
+		}
+		msg, err := sendMessage(req, q, conversation.ID, userID, body)
+		if err != nil {
+			return err
+		}
+		message = &msg
+		return nil
+	})
+	if errors.Is(err, errConversationBlocked) {
+		respondWithError(w, req, http.StatusForbidden, err.Error(), err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't create conversation", err)
+		return
+	}
+
+	conversations, err := cfg.withParticipants(req, userID, []database.ListConversationsRow{{
+		ID:        conversation.ID,
+		CreatedAt: conversation.CreatedAt,
+		UpdatedAt: conversation.UpdatedAt,
+	}})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't retrieve participants", err)
+		return
+	}
+	if message != nil {
+		cfg.publishMessage(req, conversations[0], *message)
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	respondWithJSON(w, status, conversations[0])
+}
+
+func (cfg *apiConfig) handlerConversationsList(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.requireUser(w, req)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := paginationFromRequest(req)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	rows, err := cfg.db.ListConversations(req.Context(), database.ListConversationsParams{
+		UserID:     userID,
+		PageLimit:  limit,
+		PageOffset: offset,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't retrieve conversations", err)
+		return
+	}
+
+	conversations, err := cfg.withParticipants(req, userID, rows)
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't retrieve participants", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, conversations)
+}
+
+// handlerMessagesList returns a page of a conversation's history, newest first.
+func (cfg *apiConfig) handlerMessagesList(w http.ResponseWriter, req *http.Request) {
+	userID, conversation, ok := cfg.conversationFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := paginationFromRequest(req)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	messages, err := cfg.db.ListMessages(req.Context(), database.ListMessagesParams{
+		UserID:         userID,
+		ConversationID: conversation.ID,
+		PageLimit:      limit,
+		PageOffset:     offset,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't retrieve messages", err)
+		return
+	}
+
+	resp := make([]Message, 0, len(messages))
+	for _, m := range messages {
+		resp = append(resp, mapMessage(m))
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerMessagesCreate(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	userID, conversation, ok := cfg.conversationFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(req.Body, &params)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "couldn't decode parameters", err)
+		return
+	}
+
+	body, err := cfg.validateMessage(params.Body)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	var message Message
+	err = cfg.withTx(req.Context(), func(q database.Querier) error {
+		message, err = sendMessage(req, q, conversation.ID, userID, body)
+		return err
+	})
+	if errors.Is(err, errConversationBlocked) {
+		respondWithError(w, req, http.StatusForbidden, err.Error(), err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't send message", err)
+		return
+	}
+
+	conversations, err := cfg.withParticipants(req, userID, []database.ListConversationsRow{{ID: conversation.ID}})
+	if err == nil {
+		cfg.publishMessage(req, conversations[0], message)
+	}
+
+	respondWithJSON(w, http.StatusCreated, message)
+}
+
+// handlerConversationsRead records a read receipt for the caller.
+func (cfg *apiConfig) handlerConversationsRead(w http.ResponseWriter, req *http.Request) {
+	userID, conversation, ok := cfg.conversationFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.db.MarkConversationRead(req.Context(), database.MarkConversationReadParams{
+		ConversationID: conversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't mark conversation as read", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerConversationsBlock stops anyone from sending to the conversation
+// until the participant who blocked it unblocks it.
+func (cfg *apiConfig) handlerConversationsBlock(w http.ResponseWriter, req *http.Request) {
+	cfg.setConversationBlocked(w, req, true)
+}
+
+func (cfg *apiConfig) handlerConversationsUnblock(w http.ResponseWriter, req *http.Request) {
+	cfg.setConversationBlocked(w, req, false)
+}
+
+func (cfg *apiConfig) setConversationBlocked(w http.ResponseWriter, req *http.Request, blocked bool) {
+	userID, conversation, ok := cfg.conversationFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.db.SetConversationBlocked(req.Context(), database.SetConversationBlockedParams{
+		Blocked:        blocked,
+		ConversationID: conversation.ID,
+		UserID:         userID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't update conversation", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// conversationFromRequest authenticates the caller and loads the conversation
+// named in the path. Conversations the caller isn't part of are reported as
+// not found.
+func (cfg *apiConfig) conversationFromRequest(w http.ResponseWriter, req *http.Request) (uuid.UUID, database.Conversation, bool) {
+	userID, ok := cfg.requireUser(w, req)
+	if !ok {
+		return uuid.Nil, database.Conversation{}, false
+	}
+
+	conversationID, err := uuid.Parse(req.PathValue("conversationID"))
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "invalid conversation ID", err)
+		return uuid.Nil, database.Conversation{}, false
+	}
+
+	conversation, err := cfg.db.GetConversation(req.Context(), database.GetConversationParams{
+		ID:     conversationID,
+		UserID: userID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusNotFound, "couldn't find conversation", err)
+		return uuid.Nil, database.Conversation{}, false
+	}
+
+	return userID, conversation, true
+}
+
+// withParticipants attaches participants to a page of conversations.
+func (cfg *apiConfig) withParticipants(req *http.Request, userID uuid.UUID, rows []database.ListConversationsRow) ([]Conversation, error) {
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+
+	participants, err := cfg.db.ListConversationParticipants(req.Context(), database.ListConversationParticipantsParams{
+		ConversationIds: ids,
+		UserID:          userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byConversation := map[uuid.UUID][]ConversationParticipant{}
+	for _, p := range participants {
+		byConversation[p.ConversationID] = append(byConversation[p.ConversationID], mapConversationParticipant(p))
+	}
+
+	conversations := make([]Conversation, 0, len(rows))
+	for _, row := range rows {
+		conversations = append(conversations, Conversation{
+			ID:           row.ID,
+			CreatedAt:    row.CreatedAt,
+			UpdatedAt:    row.UpdatedAt,
+			Participants: byConversation[row.ID],
+			UnreadCount:  row.UnreadCount,
+		})
+	}
+	return conversations, nil
+}
+
+// publishMessage pushes a new message to the other participants' notification topics.
+func (cfg *apiConfig) publishMessage(req *http.Request, conversation Conversation, message Message) {
+	topics := []string{}
+	for _, p := range conversation.Participants {
+		if p.UserID != message.SenderID {
+			topics = append(topics, realtime.NotificationsTopic(p.UserID))
+		}
+	}
+	cfg.publishEvent(req.Context(), eventMessageCreated, message.SenderID, topics, message)
+}
+
+func sendMessage(req *http.Request, q database.Querier, conversationID, senderID uuid.UUID, body string) (Message, error) {
+	msg, err := q.CreateMessage(req.Context(), database.CreateMessageParams{
+		Body:           body,
+		ConversationID: conversationID,
+		SenderID:       senderID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Message{}, errConversationBlocked
+	}
+	if err != nil {
+		return Message{}, err
+	}
+
+	err = q.TouchConversation(req.Context(), conversationID)
+	if err != nil {
+		return Message{}, err
+	}
+	return mapMessage(msg), nil
+}
+
+// validateMessage runs a message through the same content filter as chirps.
+// Private messages aren't queued for review, so flagged words are let through.
+func (cfg *apiConfig) validateMessage(body string) (string, error) {
+	if body == "" {
+		return "", errors.New("message body is required")
+	}
+	if contentfilter.Length(body) > maxMessageLength {
+		return "", fmt.Errorf("Message is too long: the limit is %d characters", maxMessageLength)
+	}
+
+	res := cfg.contentFilter.Check(body)
+	if res.Rejected {
+		return "", errors.New("Message contains a word that isn't allowed")
+	}
+	return res.Text, nil
+}
+
+func mapMessage(m database.Message) Message {
+	return Message{
+		ID:             m.ID,
+		CreatedAt:      m.CreatedAt,
+		ConversationID: m.ConversationID,
+		SenderID:       m.SenderID,
+		Body:           m.Body,
+	}
+}
+
+func mapConversationParticipant(p database.ConversationParticipant) ConversationParticipant {
+	participant := ConversationParticipant{
+		UserID:   p.UserID,
+		JoinedAt: p.JoinedAt,
+		Blocked:  p.BlockedAt.Valid,
+	}
+	if p.LastReadAt.Valid {
+		participant.LastReadAt = &p.LastReadAt.Time
+	}
+	return participant
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	followStatusPending  = "pending"
+	followStatusAccepted = "accepted"
+)
+
+type FollowRequest struct {
+	FollowerID uuid.UUID `json:"follower_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// handlerFollowsCreate follows a user. Following a protected account files a
+// request instead, which is reported with 202 Accepted until it's approved.
+func (cfg *apiConfig) handlerFollowsCreate(w http.ResponseWriter, req *http.Request) {
+	followerID, followee, ok := cfg.userTarget(w, req)
+	if !ok {
+		return
+	}
+	if followee.ID == followerID {
+		respondWithError(w, req, http.StatusBadRequest, "you can't follow yourself", nil)
+		return
+	}
+	if !cfg.requireNotBlocked(w, req, followee.ID, followerID) {
+		return
+	}
+
+	follow, err := cfg.db.FollowUser(req.Context(), database.FollowUserParams{
+		FollowerID: followerID,
+		FolloweeID: followee.ID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Already following or already requested.
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to follow user", err)
+		return
+	}
+
+	if follow.Status == followStatusPending {
+		cfg.notify(notificationJob{
+			Type:       notificationTypeFollowRequest,
+			ActorID:    followerID,
+			Recipients: []uuid.UUID{followee.ID},
+		})
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	cfg.notify(notificationJob{
+		Type:       notificationTypeFollow,
+		ActorID:    followerID,
+		Recipients: []uuid.UUID{followee.ID},
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerFollowsDelete(w http.ResponseWriter, req *http.Request) {
+	followerID, followee, ok := cfg.userTarget(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.UnfollowUser(req.Context(), database.UnfollowUserParams{
+		FollowerID: followerID,
+		FolloweeID: followee.ID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to unfollow user", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerFollowRequestsList returns the caller's pending follow requests, oldest first.
+func (cfg *apiConfig) handlerFollowRequestsList(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.requireUser(w, req)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := paginationFromRequest(req)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	follows, err := cfg.db.ListFollowRequests(req.Context(), database.ListFollowRequestsParams{
+		FolloweeID: userID,
+		PageLimit:  limit,
+		PageOffset: offset,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't retrieve follow requests", err)
+		return
+	}
+
+	resp := make([]FollowRequest, 0, len(follows))
+	for _, f := range follows {
+		resp = append(resp, FollowRequest{
+			FollowerID: f.FollowerID,
+			CreatedAt:  f.CreatedAt,
+		})
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerFollowRequestsAccept(w http.ResponseWriter, req *http.Request) {
+	userID, follower, ok := cfg.userTarget(w, req)
+	if !ok {
+		return
+	}
+
+	accepted, err := cfg.db.AcceptFollowRequest(req.Context(), database.AcceptFollowRequestParams{
+		FollowerID: follower.ID,
+		FolloweeID: userID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to accept follow request", err)
+		return
+	}
+	if accepted == 0 {
+		respondWithError(w, req, http.StatusNotFound, "couldn't find follow request", nil)
+		return
+	}
+
+	cfg.notify(notificationJob{
+		Type:       notificationTypeFollowAccepted,
+		ActorID:    userID,
+		Recipients: []uuid.UUID{follower.ID},
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerFollowRequestsReject(w http.ResponseWriter, req *http.Request) {
+	userID, follower, ok := cfg.userTarget(w, req)
+	if !ok {
+		return
+	}
+
+	rejected, err := cfg.db.RejectFollowRequest(req.Context(), database.RejectFollowRequestParams{
+		FollowerID: follower.ID,
+		FolloweeID: userID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to reject follow request", err)
+		return
+	}
+	if rejected == 0 {
+		respondWithError(w, req, http.StatusNotFound, "couldn't find follow request", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userTarget authenticates the caller and loads the user named in the path.
+func (cfg *apiConfig) userTarget(w http.ResponseWriter, req *http.Request) (uuid.UUID, database.User, bool) {
+	callerID, ok := cfg.requireUser(w, req)
+	if !ok {
+		return uuid.Nil, database.User{}, false
+	}
+
+	targetID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "invalid user ID", err)
+		return uuid.Nil, database.User{}, false
+	}
+
+	target, err := cfg.db.GetUserByID(req.Context(), targetID)
+	if err != nil {
+		respondWithError(w, req, http.StatusNotFound, "couldn't find user", err)
+		return uuid.Nil, database.User{}, false
+	}
+
+	return callerID, target, true
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/realtime"
+	"github.com/google/uuid"
+)
+
+type Like struct {
+	ChirpID uuid.UUID `json:"chirp_id"`
+	UserID  uuid.UUID `json:"user_id"`
+}
+
+func (cfg *apiConfig) handlerLikesCreate(w http.ResponseWriter, req *http.Request) {
+	userID, chirp, ok := cfg.chirpTarget(w, req)
+	if !ok {
+		return
+	}
+	if !cfg.requireNotBlocked(w, req, chirp.UserID, userID) {
+		return
+	}
+
+	added, err := cfg.db.LikeChirp(req.Context(), database.LikeChirpParams{
+		UserID:  userID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to like chirp", err)
+		return
+	}
+
+	if added > 0 {
+		if cfg.streamable(req.Context(), mapChirp(chirp)) {
+			topics := []string{
+				realtime.UserTopic(chirp.UserID),
+				realtime.ThreadTopic(chirp.ID),
+			}
+			cfg.publishEvent(req.Context(), realtime.EventChirpLiked, chirp.UserID, topics, Like{
+				ChirpID: chirp.ID,
+				UserID:  userID,
+			})
+		}
+		cfg.notify(notificationJob{
+			Type:       notificationTypeLike,
+			ActorID:    userID,
+			Recipients: []uuid.UUID{chirp.UserID},
+			ChirpID:    chirp.ID,
+		})
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerLikesDelete(w http.ResponseWriter, req *http.Request) {
+	userID, chirp, ok := cfg.chirpTarget(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.UnlikeChirp(req.Context(), database.UnlikeChirpParams{
+		UserID:  userID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to unlike chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// chirpTarget authenticates the caller and loads the chirp named in the path.
+func (cfg *apiConfig) chirpTarget(w http.ResponseWriter, req *http.Request) (uuid.UUID, database.Chirp, bool) {
+	userID, ok := cfg.requireUser(w, req)
+	if !ok {
+		return uuid.Nil, database.Chirp{}, false
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "invalid chirp ID", err)
+		return uuid.Nil, database.Chirp{}, false
+	}
+
+	chirp, err := cfg.db.GetChirp(req.Context(), database.GetChirpParams{
+		ID:       chirpID,
+		ViewerID: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusNotFound, "couldn't retrieve chirp", err)
+		return uuid.Nil, database.Chirp{}, false
+	}
+
+	return userID, chirp, true
+}
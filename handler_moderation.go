@@ -0,0 +1,536 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/realtime"
+	"github.com/CybrRonin/Chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+const (
+	moderationActionDismissReport = "dismiss_report"
+	moderationActionHideChirp     = "hide_chirp"
+	moderationActionUnhideChirp   = "unhide_chirp"
+	moderationActionRemoveChirp   = "remove_chirp"
+	moderationActionSuspendUser   = "suspend_user"
+	moderationActionUnsuspendUser = "unsuspend_user"
+	moderationActionBanUser       = "ban_user"
+	moderationActionUnbanUser     = "unban_user"
+)
+
+type ModerationAction struct {
+	ID          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ModeratorID uuid.UUID  `json:"moderator_id"`
+	Action      string     `json:"action"`
+	ChirpID     *uuid.UUID `json:"chirp_id,omitempty"`
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	ReportID    *uuid.UUID `json:"report_id,omitempty"`
+	Note        string     `json:"note"`
+}
+
+// moderationParameters is the optional body every moderation action accepts.
+// The note is kept in the moderation log.
+type moderationParameters struct {
+	Note string `json:"note"`
+}
+
+// handlerReportsList is the moderation queue. It lists open reports, oldest
+// first, unless another status is asked for.
+func (cfg *apiConfig) handlerReportsList(w http.ResponseWriter, req *http.Request) {
+	_, ok := cfg.requireRole(w, req, roleModerator, roleAdmin)
+	if !ok {
+		return
+	}
+
+	status := req.URL.Query().Get("status")
+	if status == "" {
+		status = reportStatusOpen
+	}
+	if !slices.Contains([]string{reportStatusOpen, reportStatusActioned, reportStatusDismissed}, status) {
+		respondWithError(w, req, http.StatusBadRequest, "unknown report status", nil)
+		return
+	}
+
+	limit, offset, err := paginationFromRequest(req)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	reports, err := cfg.db.ListReports(req.Context(), database.ListReportsParams{
+		Status:     status,
+		PageLimit:  limit,
+		PageOffset: offset,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't retrieve reports", err)
+		return
+	}
+
+	resp := make([]Report, 0, len(reports))
+	for _, r := range reports {
+		resp = append(resp, mapReport(r))
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerReportsDismiss(w http.ResponseWriter, req *http.Request) {
+	moderator, params, ok := cfg.moderationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	reportID, err := uuid.Parse(req.PathValue("reportID"))
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "invalid report ID", err)
+		return
+	}
+
+	var report database.Report
+	err = cfg.withTx(req.Context(), func(q database.Querier) error {
+		report, err = q.ResolveReport(req.Context(), database.ResolveReportParams{
+			ID:         reportID,
+			Status:     reportStatusDismissed,
+			ResolvedBy: uuid.NullUUID{UUID: moderator.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		return recordModerationAction(req, q, database.CreateModerationActionParams{
+			ModeratorID: moderator.ID,
+			Action:      moderationActionDismissReport,
+			ChirpID:     report.ChirpID,
+			UserID:      uuid.NullUUID{UUID: report.ReportedUserID, Valid: true},
+			ReportID:    uuid.NullUUID{UUID: report.ID, Valid: true},
+			Note:        params.Note,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, req, http.StatusNotFound, "couldn't find an open report", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't dismiss report", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, mapReport(report))
+}
+
+// handlerChirpsHide hides a chirp from everyone but its author and closes
+// its open reports.
+func (cfg *apiConfig) handlerChirpsHide(w http.ResponseWriter, req *http.Request) {
+	cfg.setChirpHidden(w, req, true)
+}
+
+func (cfg *apiConfig) handlerChirpsUnhide(w http.ResponseWriter, req *http.Request) {
+	cfg.setChirpHidden(w, req, false)
+}
+
+func (cfg *apiConfig) setChirpHidden(w http.ResponseWriter, req *http.Request, hidden bool) {
+	moderator, chirp, params, ok := cfg.moderatedChirp(w, req)
+	if !ok {
+		return
+	}
+
+	action := moderationActionUnhideChirp
+	if hidden {
+		action = moderationActionHideChirp
+	}
+
+	err := cfg.withTx(req.Context(), func(q database.Querier) error {
+		_, err := q.SetChirpHidden(req.Context(), database.SetChirpHiddenParams{
+			Hidden: hidden,
+			ID:     chirp.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if hidden {
+			_, err = q.ResolveReportsForChirp(req.Context(), database.ResolveReportsForChirpParams{
+				ChirpID:    uuid.NullUUID{UUID: chirp.ID, Valid: true},
+				ResolvedBy: uuid.NullUUID{UUID: moderator.ID, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return recordModerationAction(req, q, database.CreateModerationActionParams{
+			ModeratorID: moderator.ID,
+			Action:      action,
+			ChirpID:     uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			UserID:      uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+			Note:        params.Note,
+		})
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't update chirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerChirpsRemove deletes a chirp on a moderator's behalf. Its open
+// reports are closed first so they stay in the queue history. A chirp the
+// author already deleted is taken over by the moderator, but subscribers
+// aren't told about the deletion a second time.
+func (cfg *apiConfig) handlerChirpsRemove(w http.ResponseWriter, req *http.Request) {
+	moderator, chirp, params, ok := cfg.moderatedChirp(w, req)
+	if !ok {
+		return
+	}
+	alreadyDeleted := chirp.DeletedAt.Valid
+
+	err := cfg.withTx(req.Context(), func(q database.Querier) error {
+		_, err := q.ResolveReportsForChirp(req.Context(), database.ResolveReportsForChirpParams{
+			ChirpID:    uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			ResolvedBy: uuid.NullUUID{UUID: moderator.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		event := cfg.newAuditEvent(req, auditChirpRemoved)
+		event.ActorID = moderator.ID
+		event.TargetID = chirp.UserID
+		event.Payload = map[string]string{
+			"chirp_id": chirp.ID.String(),
+			"note":     params.Note,
+		}
+		err = recordAuditEvent(req.Context(), q, event)
+		if err != nil {
+			return err
+		}
+		err = recordModerationAction(req, q, database.CreateModerationActionParams{
+			ModeratorID: moderator.ID,
+			Action:      moderationActionRemoveChirp,
+			ChirpID:     uuid.NullUUID{UUID: chirp.ID, Valid: true},
+			UserID:      uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+			Note:        params.Note,
+		})
+		if err != nil {
+			return err
+		}
+		removed, err := q.RemoveChirp(req.Context(), database.RemoveChirpParams{
+			DeletedBy: uuid.NullUUID{UUID: moderator.ID, Valid: true},
+			ID:        chirp.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			// Purged since it was loaded.
+			return sql.ErrNoRows
+		}
+		if alreadyDeleted {
+			return nil
+		}
+		return cfg.enqueueChirpWebhook(req.Context(), q, webhooks.EventChirpDeleted, mapChirp(chirp))
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, req, http.StatusNotFound, "couldn't find chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't remove chirp", err)
+		return
+	}
+
+	if !alreadyDeleted {
+		cfg.publishChirpEvent(req.Context(), realtime.EventChirpDeleted, mapChirp(chirp))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerUsersSuspend suspends a user for the given duration. Their refresh
+// tokens are revoked and their open reports closed, and hide_chirps hides
+// their chirps from everyone else until the suspension ends.
+func (cfg *apiConfig) handlerUsersSuspend(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		restrictionParameters
+		Duration string `json:"duration"`
+	}
+
+	moderator, ok := cfg.requireRole(w, req, roleModerator, roleAdmin)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	target, ok := cfg.restrictionTarget(w, req, moderator, &params)
+	if !ok {
+		return
+	}
+	duration, err := time.ParseDuration(params.Duration)
+	if err != nil || duration <= 0 {
+		respondWithError(w, req, http.StatusBadRequest, "duration must be a positive duration such as 72h", err)
+		return
+	}
+
+	cfg.restrictUser(w, req, moderator, moderationActionSuspendUser, params.Note, func(q database.Querier) (database.User, error) {
+		return q.SuspendUser(req.Context(), database.SuspendUserParams{
+			ID:               target,
+			SuspendedUntil:   sql.NullTime{Time: time.Now().UTC().Add(duration), Valid: true},
+			ModerationReason: params.Reason,
+			ChirpsHidden:     params.HideChirps,
+		})
+	})
+}
+
+func (cfg *apiConfig) handlerUsersUnsuspend(w http.ResponseWriter, req *http.Request) {
+	moderator, params, ok := cfg.moderationRequest(w, req)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "invalid user ID", err)
+		return
+	}
+
+	cfg.restrictUser(w, req, moderator, moderationActionUnsuspendUser, params.Note, func(q database.Querier) (database.User, error) {
+		return q.UnsuspendUser(req.Context(), userID)
+	})
+}
+
+// handlerUsersBan bans a user indefinitely. Only admins can ban.
+func (cfg *apiConfig) handlerUsersBan(w http.ResponseWriter, req *http.Request) {
+	admin, ok := cfg.requireRole(w, req, roleAdmin)
+	if !ok {
+		return
+	}
+
+	params := restrictionParameters{}
+	target, ok := cfg.restrictionTarget(w, req, admin, &params)
+	if !ok {
+		return
+	}
+
+	cfg.restrictUser(w, req, admin, moderationActionBanUser, params.Note, func(q database.Querier) (database.User, error) {
+		return q.BanUser(req.Context(), database.BanUserParams{
+			ID:               target,
+			ModerationReason: params.Reason,
+			ChirpsHidden:     params.HideChirps,
+		})
+	})
+}
+
+func (cfg *apiConfig) handlerUsersUnban(w http.ResponseWriter, req *http.Request) {
+	admin, ok := cfg.requireRole(w, req, roleAdmin)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "invalid user ID", err)
+		return
+	}
+
+	params := moderationParameters{}
+	if req.ContentLength != 0 {
+		err = decodeJSON(req.Body, &params)
+		if err != nil {
+			respondWithError(w, req, http.StatusBadRequest, "couldn't decode parameters", err)
+			return
+		}
+	}
+
+	cfg.restrictUser(w, req, admin, moderationActionUnbanUser, params.Note, func(q database.Querier) (database.User, error) {
+		return q.UnbanUser(req.Context(), userID)
+	})
+}
+
+// restrictionParameters is the body accepted when suspending or banning a
+// user. The reason is shown to the user; the note is only kept in the
+// moderation log.
+type restrictionParameters struct {
+	moderationParameters
+	Reason     string `json:"reason"`
+	HideChirps bool   `json:"hide_chirps"`
+}
+
+// restrictionTarget parses the user named in the path and decodes params. A
+// moderator can't suspend or ban themselves, or anyone whose role is the same
+// as or above their own.
+func (cfg *apiConfig) restrictionTarget(w http.ResponseWriter, req *http.Request, moderator database.User, params any) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(req.PathValue("userID"))
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "invalid user ID", err)
+		return uuid.Nil, false
+	}
+	if userID == moderator.ID {
+		respondWithError(w, req, http.StatusBadRequest, "you can't restrict your own account", nil)
+		return uuid.Nil, false
+	}
+
+	target, err := cfg.db.GetUserByID(req.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, req, http.StatusNotFound, "couldn't find user", err)
+		return uuid.Nil, false
+	}
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't retrieve user", err)
+		return uuid.Nil, false
+	}
+	if roleRank(target.Role) >= roleRank(moderator.Role) {
+		respondWithError(w, req, http.StatusForbidden, "you can't restrict an account with the same or a higher role", nil)
+		return uuid.Nil, false
+	}
+
+	err = decodeJSON(req.Body, params)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "couldn't decode parameters", err)
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// restrictUser applies a suspension or ban change and records it in the
+// moderation log. Suspending or banning a user also revokes their refresh
+// tokens and closes the open reports against them.
+func (cfg *apiConfig) restrictUser(w http.ResponseWriter, req *http.Request, moderator database.User, action, note string, update func(q database.Querier) (database.User, error)) {
+	var user database.User
+	err := cfg.withTx(req.Context(), func(q database.Querier) error {
+		var err error
+		user, err = update(q)
+		if err != nil {
+			return err
+		}
+		if action == moderationActionSuspendUser || action == moderationActionBanUser {
+			_, err = q.RevokeUserRefreshTokens(req.Context(), user.ID)
+			if err != nil {
+				return err
+			}
+			_, err = q.ResolveReportsForUser(req.Context(), database.ResolveReportsForUserParams{
+				ReportedUserID: user.ID,
+				ResolvedBy:     uuid.NullUUID{UUID: moderator.ID, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return recordModerationAction(req, q, database.CreateModerationActionParams{
+			ModeratorID: moderator.ID,
+			Action:      action,
+			UserID:      uuid.NullUUID{UUID: user.ID, Valid: true},
+			Note:        note,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, req, http.StatusNotFound, "couldn't find user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't update user", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, mapUser(user))
+}
+
+// handlerModerationLog is the audit trail of moderation actions, newest first.
+func (cfg *apiConfig) handlerModerationLog(w http.ResponseWriter, req *http.Request) {
+	_, ok := cfg.requireRole(w, req, roleModerator, roleAdmin)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := paginationFromRequest(req)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	actions, err := cfg.db.ListModerationActions(req.Context(), database.ListModerationActionsParams{
+		PageLimit:  limit,
+		PageOffset: offset,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't retrieve moderation log", err)
+		return
+	}
+
+	resp := make([]ModerationAction, 0, len(actions))
+	for _, a := range actions {
+		resp = append(resp, mapModerationAction(a))
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// moderationRequest checks the caller is a moderator and decodes the optional note.
+func (cfg *apiConfig) moderationRequest(w http.ResponseWriter, req *http.Request) (database.User, moderationParameters, bool) {
+	moderator, ok := cfg.requireRole(w, req, roleModerator, roleAdmin)
+	if !ok {
+		return database.User{}, moderationParameters{}, false
+	}
+
+	params := moderationParameters{}
+	if req.ContentLength != 0 {
+		err := decodeJSON(req.Body, &params)
+		if err != nil {
+			respondWithError(w, req, http.StatusBadRequest, "couldn't decode parameters", err)
+			return database.User{}, moderationParameters{}, false
+		}
+	}
+
+	return moderator, params, true
+}
+
+// moderatedChirp loads the chirp named in the path for a moderation action,
+// regardless of its visibility.
+func (cfg *apiConfig) moderatedChirp(w http.ResponseWriter, req *http.Request) (database.User, database.Chirp, moderationParameters, bool) {
+	moderator, params, ok := cfg.moderationRequest(w, req)
+	if !ok {
+		return database.User{}, database.Chirp{}, moderationParameters{}, false
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "invalid chirp ID", err)
+		return database.User{}, database.Chirp{}, moderationParameters{}, false
+	}
+
+	chirp, err := cfg.db.GetChirpForModeration(req.Context(), chirpID)
+	if err != nil {
+		respondWithError(w, req, http.StatusNotFound, "couldn't find chirp", err)
+		return database.User{}, database.Chirp{}, moderationParameters{}, false
+	}
+
+	return moderator, chirp, params, true
+}
+
+func recordModerationAction(req *http.Request, q database.Querier, params database.CreateModerationActionParams) error {
+	_, err := q.CreateModerationAction(req.Context(), params)
+	return err
+}
+
+func mapModerationAction(a database.ModerationAction) ModerationAction {
+	action := ModerationAction{
+		ID:          a.ID,
+		CreatedAt:   a.CreatedAt,
+		ModeratorID: a.ModeratorID,
+		Action:      a.Action,
+		Note:        a.Note,
+	}
+	if a.ChirpID.Valid {
+		action.ChirpID = &a.ChirpID.UUID
+	}
+	if a.UserID.Valid {
+		action.UserID = &a.UserID.UUID
+	}
+	if a.ReportID.Valid {
+		action.ReportID = &a.ReportID.UUID
+	}
+	return action
+}
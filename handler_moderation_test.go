@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRestrictionRequiresHigherRole(t *testing.T) {
+	tests := []struct {
+		name       string
+		callerRole string
+		targetRole string
+		ban        bool
+		want       int
+	}{
+		{"moderator suspends user", roleModerator, roleUser, false, http.StatusOK},
+		{"moderator suspends moderator", roleModerator, roleModerator, false, http.StatusForbidden},
+		{"moderator suspends admin", roleModerator, roleAdmin, false, http.StatusForbidden},
+		{"admin suspends moderator", roleAdmin, roleModerator, false, http.StatusOK},
+		{"admin bans moderator", roleAdmin, roleModerator, true, http.StatusOK},
+		{"admin bans admin", roleAdmin, roleAdmin, true, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newFakeDB()
+			cfg := newTestConfig(t, db)
+			caller := db.addUser(tt.callerRole)
+			target := db.addUser(tt.targetRole)
+
+			handler, path := cfg.handlerUsersSuspend, "/suspend"
+			body := map[string]any{"duration": "24h", "reason": "spam"}
+			if tt.ban {
+				handler, path = cfg.handlerUsersBan, "/ban"
+				body = map[string]any{"reason": "spam"}
+			}
+			req := newTestRequest(t, http.MethodPost, "/admin/users/"+target.ID.String()+path, caller.ID, body)
+			req.SetPathValue("userID", target.ID.String())
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			allowed := tt.want == http.StatusOK
+			restricted := db.users[target.ID].SuspendedUntil.Valid || db.users[target.ID].BannedAt.Valid
+			if restricted != allowed {
+				t.Errorf("target restricted = %v, want %v", restricted, allowed)
+			}
+			if logged := len(db.modActions) > 0; logged != allowed {
+				t.Errorf("moderation action logged = %v, want %v", logged, allowed)
+			}
+		})
+	}
+}
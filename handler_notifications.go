@@ -0,0 +1,177 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerNotificationsList(w http.ResponseWriter, req *http.Request) {
+	type response struct {
+		UnreadCount   int64          `json:"unread_count"`
+		Notifications []Notification `json:"notifications"`
+	}
+
+	userID, ok := cfg.requireUser(w, req)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := paginationFromRequest(req)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	notifications, err := cfg.db.ListNotifications(req.Context(), database.ListNotificationsParams{
+		UserID:     userID,
+		UnreadOnly: req.URL.Query().Get("unread") == "true",
+		PageLimit:  limit,
+		PageOffset: offset,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't retrieve notifications", err)
+		return
+	}
+
+	unread, err := cfg.db.CountUnreadNotifications(req.Context(), userID)
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't count unread notifications", err)
+		return
+	}
+
+	resp := response{
+		UnreadCount:   unread,
+		Notifications: make([]Notification, 0, len(notifications)),
+	}
+	for _, n := range notifications {
+		resp.Notifications = append(resp.Notifications, mapNotification(n))
+	}
+
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerNotificationsRead(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		IDs []uuid.UUID `json:"ids"`
+		All bool        `json:"all"`
+	}
+	type response struct {
+		Marked int64 `json:"marked"`
+	}
+
+	userID, ok := cfg.requireUser(w, req)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(req.Body, &params)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "couldn't decode parameters", err)
+		return
+	}
+	if !params.All && len(params.IDs) == 0 {
+		respondWithError(w, req, http.StatusBadRequest, "either ids or all must be set", nil)
+		return
+	}
+
+	var marked int64
+	if params.All {
+		marked, err = cfg.db.MarkAllNotificationsRead(req.Context(), userID)
+	} else {
+		marked, err = cfg.db.MarkNotificationsRead(req.Context(), database.MarkNotificationsReadParams{
+			UserID: userID,
+			Ids:    params.IDs,
+		})
+	}
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't mark notifications as read", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response{Marked: marked})
+}
+
+func (cfg *apiConfig) handlerNotificationPreferencesGet(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.requireUser(w, req)
+	if !ok {
+		return
+	}
+
+	prefs, err := cfg.notificationPreferences(req, userID)
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't retrieve notification preferences", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, prefs)
+}
+
+// handlerNotificationPreferencesUpdate takes a map of notification type to
+// enabled. Types left out of the request keep their current setting.
+func (cfg *apiConfig) handlerNotificationPreferencesUpdate(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.requireUser(w, req)
+	if !ok {
+		return
+	}
+
+	params := map[string]bool{}
+	err := decodeJSON(req.Body, &params)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "couldn't decode parameters", err)
+		return
+	}
+	for notificationType := range params {
+		if !slices.Contains(notificationTypes, notificationType) {
+			respondWithError(w, req, http.StatusBadRequest, "unknown notification type: "+notificationType, nil)
+			return
+		}
+	}
+
+	err = cfg.withTx(req.Context(), func(q database.Querier) error {
+		for notificationType, enabled := range params {
+			err := q.UpsertNotificationPreference(req.Context(), database.UpsertNotificationPreferenceParams{
+				UserID:  userID,
+				Type:    notificationType,
+				Enabled: enabled,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't update notification preferences", err)
+		return
+	}
+
+	prefs, err := cfg.notificationPreferences(req, userID)
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't retrieve notification preferences", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, prefs)
+}
+
+// notificationPreferences returns every notification type with its setting.
+// Types the user never changed are enabled.
+func (cfg *apiConfig) notificationPreferences(req *http.Request, userID uuid.UUID) (map[string]bool, error) {
+	stored, err := cfg.db.ListNotificationPreferences(req.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := make(map[string]bool, len(notificationTypes))
+	for _, notificationType := range notificationTypes {
+		prefs[notificationType] = true
+	}
+	for _, pref := range stored {
+		prefs[pref.Type] = pref.Enabled
+	}
+	return prefs, nil
+}
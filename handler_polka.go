@@ -1,53 +1,299 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/subtle"
 	"database/sql"
 	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/CybrRonin/Chirpy/internal/auth"
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/entitlements"
+	"github.com/CybrRonin/Chirpy/internal/webhooks"
 	"github.com/google/uuid"
 )
 
-func (cfg *apiConfig) handlerUpgradeUser(w http.ResponseWriter, req *http.Request) {
-	type requestParams struct {
-		Event string `json:"event"`
-		Data  struct {
-			UserID uuid.UUID `json:"user_id"`
-		} `json:"data"`
-	}
+const (
+	maxWebhookBodyBytes = 1 << 20
+
+	webhookProviderPolka = "polka"
+
+	webhookStatusPending   = "pending"
+	webhookStatusProcessed = "processed"
+	webhookStatusIgnored   = "ignored"
+	webhookStatusFailed    = "failed"
+)
+
+const (
+	polkaEventUserUpgraded          = "user.upgraded"
+	polkaEventUserDowngraded        = "user.downgraded"
+	polkaEventSubscriptionCancelled = "subscription.cancelled"
+	polkaEventSubscriptionRenewed   = "subscription.renewed"
+)
+
+type polkaEvent struct {
+	ID    string `json:"id"`
+	Event string `json:"event"`
+	Data  struct {
+		UserID uuid.UUID `json:"user_id"`
+		// CurrentPeriodEnd is optional; we assume a standard billing period when it's missing.
+		CurrentPeriodEnd *time.Time `json:"current_period_end"`
+	} `json:"data"`
+}
 
-	apiKey, err := auth.GetAPIKey(req.Header)
+func (cfg *apiConfig) handlerPolkaWebhooks(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookBodyBytes))
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "unable to find API key", err)
+		respondWithError(w, req, http.StatusBadRequest, "couldn't read request", err)
 		return
 	}
-	if apiKey != cfg.polkaKey {
-		respondWithError(w, http.StatusUnauthorized, "API key is invalid", err)
+
+	err = cfg.authenticatePolka(req.Header, body)
+	if err != nil {
+		cfg.metrics.ObserveWebhookReceived(webhookProviderPolka, "unauthorized")
+		respondWithError(w, req, http.StatusUnauthorized, "couldn't authenticate webhook", err)
 		return
 	}
 
-	reqParams := requestParams{}
-	err = decodeJSON(req.Body, &reqParams)
+	reqParams := polkaEvent{}
+	err = decodeJSON(bytes.NewReader(body), &reqParams)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "couldn't decode request", err)
+		respondWithError(w, req, http.StatusBadRequest, "couldn't decode request", err)
 		return
 	}
 
-	if reqParams.Event != "user.upgraded" {
+	event, duplicate, err := cfg.processWebhookEvent(req.Context(), func(q database.Querier) (database.WebhookEvent, error) {
+		return claimPolkaEvent(req.Context(), q, reqParams, body)
+	})
+	if duplicate {
+		cfg.metrics.ObserveWebhookReceived(webhookProviderPolka, "duplicate")
+		// Polka retries until it gets a 2XX, so acknowledge without reprocessing.
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
-
-	_, err = cfg.db.UpgradeUser(req.Context(), reqParams.Data.UserID)
+	outcome := event.Status
+	if err != nil && outcome != webhookStatusFailed {
+		// The failure couldn't be recorded on the event.
+		outcome = "error"
+	}
+	cfg.metrics.ObserveWebhookReceived(webhookProviderPolka, outcome)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			respondWithError(w, http.StatusNotFound, "user not found", err)
+			respondWithError(w, req, http.StatusNotFound, "user or subscription not found", err)
 			return
 		}
-		respondWithError(w, http.StatusInternalServerError, "failed to update user", err)
+		respondWithError(w, req, http.StatusInternalServerError, "failed to process webhook event", err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// claimPolkaEvent stores a delivery in the event log, or locks the existing
+// entry when Polka has sent the same event before. Deliveries without an
+// event ID can't be told apart from a genuine repeat, so each one is new.
+func claimPolkaEvent(ctx context.Context, q database.Querier, params polkaEvent, body []byte) (database.WebhookEvent, error) {
+	eventID := sql.NullString{String: params.ID, Valid: params.ID != ""}
+	event, err := q.CreateWebhookEvent(ctx, database.CreateWebhookEventParams{
+		Provider:  webhookProviderPolka,
+		EventID:   eventID,
+		EventType: params.Event,
+		Payload:   body,
+	})
+	if !errors.Is(err, sql.ErrNoRows) || !eventID.Valid {
+		return event, err
+	}
+
+	return q.GetWebhookEventByEventID(ctx, database.GetWebhookEventByEventIDParams{
+		Provider: webhookProviderPolka,
+		EventID:  eventID,
+	})
+}
+
+// processWebhookEvent applies an event in the same transaction that claim
+// locks it in, so concurrent deliveries or replays can't both apply it.
+// duplicate is true when the event had already been handled. When applying
+// fails the transaction is rolled back and the failure is recorded on the
+// event, which stays available for replay.
+func (cfg *apiConfig) processWebhookEvent(ctx context.Context, claim func(database.Querier) (database.WebhookEvent, error)) (event database.WebhookEvent, duplicate bool, err error) {
+	var job notificationJob
+	err = cfg.withTx(ctx, func(q database.Querier) error {
+		event, err = claim(q)
+		if err != nil {
+			return err
+		}
+		if webhookEventHandled(event) {
+			duplicate = true
+			return nil
+		}
+
+		var status string
+		status, job, err = cfg.applyPolkaEvent(ctx, q, event.Payload)
+		if err != nil {
+			return err
+		}
+		processed, err := q.MarkWebhookEventProcessed(ctx, database.MarkWebhookEventProcessedParams{
+			ID:     event.ID,
+			Status: status,
+		})
+		if err != nil {
+			return err
+		}
+		event = processed
+		return nil
+	})
+	if err == nil {
+		cfg.notify(job)
+		return event, duplicate, nil
+	}
+
+	procErr := err
+	err = cfg.withTx(ctx, func(q database.Querier) error {
+		event, err = claim(q)
+		if err != nil || webhookEventHandled(event) {
+			return err
+		}
+		failed, err := q.MarkWebhookEventFailed(ctx, database.MarkWebhookEventFailedParams{
+			ID:    event.ID,
+			Error: sql.NullString{String: procErr.Error(), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		event = failed
+		return nil
+	})
+	return event, false, errors.Join(procErr, err)
+}
+
+func webhookEventHandled(event database.WebhookEvent) bool {
+	return event.Status == webhookStatusProcessed || event.Status == webhookStatusIgnored
+}
+
+// applyPolkaEvent makes the changes an event calls for using q. The returned
+// notification should only be sent once the changes are committed.
+func (cfg *apiConfig) applyPolkaEvent(ctx context.Context, q database.Querier, payload []byte) (status string, job notificationJob, err error) {
+	params := polkaEvent{}
+	err = decodeJSON(bytes.NewReader(payload), &params)
+	if err != nil {
+		return "", job, err
+	}
+
+	userID := params.Data.UserID
+	switch params.Event {
+	case polkaEventUserUpgraded:
+		err = requirePolkaUser(ctx, q, userID)
+		if err != nil {
+			return "", job, err
+		}
+		var sub database.Subscription
+		sub, err = q.UpsertSubscription(ctx, database.UpsertSubscriptionParams{
+			UserID:           userID,
+			Plan:             string(entitlements.PlanRed),
+			Status:           subscriptionStatusActive,
+			CurrentPeriodEnd: polkaPeriodEnd(params, time.Now().UTC()),
+		})
+		if err != nil {
+			return "", job, err
+		}
+		err = recordAuditEvent(ctx, q, auditEvent{
+			Type:     auditUserUpgraded,
+			TargetID: userID,
+			Payload: map[string]string{
+				"provider": webhookProviderPolka,
+				"event_id": params.ID,
+			},
+		})
+		if err != nil {
+			return "", job, err
+		}
+		err = webhooks.Enqueue(ctx, q, webhooks.EventUserUpgraded, mapSubscription(sub))
+		job = notificationJob{
+			Type:       notificationTypeUpgrade,
+			Recipients: []uuid.UUID{userID},
+		}
+	case polkaEventSubscriptionRenewed:
+		var sub database.Subscription
+		sub, err = q.GetSubscriptionByUser(ctx, userID)
+		if err != nil {
+			return "", job, err
+		}
+		// Renewals extend from the end of the paid period, not from today.
+		_, err = q.UpsertSubscription(ctx, database.UpsertSubscriptionParams{
+			UserID:           userID,
+			Plan:             sub.Plan,
+			Status:           subscriptionStatusActive,
+			CurrentPeriodEnd: polkaPeriodEnd(params, later(sub.CurrentPeriodEnd, time.Now().UTC())),
+		})
+	case polkaEventSubscriptionCancelled:
+		// Cancelled subscriptions keep their perks until the paid period ends.
+		_, err = q.UpdateSubscriptionStatus(ctx, database.UpdateSubscriptionStatusParams{
+			UserID: userID,
+			Status: subscriptionStatusCancelled,
+		})
+	case polkaEventUserDowngraded:
+		err = requirePolkaUser(ctx, q, userID)
+		if err != nil {
+			return "", job, err
+		}
+		_, err = q.UpsertSubscription(ctx, database.UpsertSubscriptionParams{
+			UserID:           userID,
+			Plan:             string(entitlements.PlanRed),
+			Status:           subscriptionStatusExpired,
+			CurrentPeriodEnd: time.Now().UTC(),
+		})
+	default:
+		return webhookStatusIgnored, job, nil
+	}
+	if err != nil {
+		return "", notificationJob{}, err
+	}
+
+	return webhookStatusProcessed, job, nil
+}
+
+// requirePolkaUser returns sql.ErrNoRows when the user an event names doesn't
+// exist. UpsertSubscription would otherwise fail on its foreign key, which
+// the handler can't tell apart from a real database error.
+func requirePolkaUser(ctx context.Context, q database.Querier, userID uuid.UUID) error {
+	_, err := q.GetUserByID(ctx, userID)
+	return err
+}
+
+// polkaPeriodEnd returns the period end Polka sent, or one billing period after from.
+func polkaPeriodEnd(params polkaEvent, from time.Time) time.Time {
+	if params.Data.CurrentPeriodEnd != nil {
+		return params.Data.CurrentPeriodEnd.UTC()
+	}
+	return from.Add(subscriptionBillingPeriod)
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// authenticatePolka accepts an HMAC signature over the raw body from any active
+// secret. Deliveries without a signature fall back to the static API key,
+// unless the legacy key has been turned off.
+func (cfg *apiConfig) authenticatePolka(headers http.Header, body []byte) error {
+	signature := headers.Get(auth.PolkaSignatureHeader)
+	if signature != "" || !cfg.polkaAllowLegacyKey {
+		return auth.VerifyWebhookSignature(signature, body, cfg.polkaSecrets, cfg.polkaSignatureTolerance, time.Now())
+	}
+
+	apiKey, err := auth.GetAPIKey(headers)
+	if err != nil {
+		return err
+	}
+	if cfg.polkaKey == "" || subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.polkaKey)) != 1 {
+		return errors.New("API key is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/entitlements"
+	"github.com/google/uuid"
+)
+
+func polkaRequest(t *testing.T, eventID, event string, userID uuid.UUID) *http.Request {
+	t.Helper()
+	body := map[string]any{
+		"event": event,
+		"data":  map[string]any{"user_id": userID},
+	}
+	if eventID != "" {
+		body["id"] = eventID
+	}
+	req := newTestRequest(t, http.MethodPost, "/api/polka/webhooks", uuid.Nil, body)
+	req.Header.Set("Authorization", "ApiKey polka-key")
+	return req
+}
+
+func TestPolkaWebhookAppliesEventOnce(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+	user := db.addUser(roleUser)
+
+	for i := range 2 {
+		rec := httptest.NewRecorder()
+		cfg.handlerPolkaWebhooks(rec, polkaRequest(t, "evt_1", polkaEventUserUpgraded, user.ID))
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("delivery %d: status = %d, want %d: %s", i+1, rec.Code, http.StatusNoContent, rec.Body)
+		}
+	}
+
+	if got := db.subscriptions[user.ID].Plan; got != string(entitlements.PlanRed) {
+		t.Errorf("plan = %q, want %q", got, entitlements.PlanRed)
+	}
+	if len(db.webhookEvents) != 1 || db.webhookEvents[0].Status != webhookStatusProcessed {
+		t.Errorf("webhook events = %+v, want one processed event", db.webhookEvents)
+	}
+	if len(db.outbox) != 1 || len(db.auditEvents) != 1 {
+		t.Errorf("got %d outbox and %d audit events, want 1 of each", len(db.outbox), len(db.auditEvents))
+	}
+}
+
+func TestPolkaWebhookWithoutEventIDIsNotDeduplicated(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+	user := db.addUser(roleUser)
+
+	for range 2 {
+		rec := httptest.NewRecorder()
+		cfg.handlerPolkaWebhooks(rec, polkaRequest(t, "", polkaEventUserUpgraded, user.ID))
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusNoContent, rec.Body)
+		}
+	}
+
+	if len(db.webhookEvents) != 2 {
+		t.Errorf("got %d webhook events, want 2", len(db.webhookEvents))
+	}
+}
+
+func TestWebhookEventReplay(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+	admin := db.addUser(roleAdmin)
+	user := db.addUser(roleUser)
+
+	payload, err := json.Marshal(map[string]any{
+		"id":    "evt_failed",
+		"event": polkaEventUserUpgraded,
+		"data":  map[string]any{"user_id": user.ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := database.WebhookEvent{
+		ID:         uuid.New(),
+		Provider:   webhookProviderPolka,
+		EventID:    sql.NullString{String: "evt_failed", Valid: true},
+		EventType:  polkaEventUserUpgraded,
+		Payload:    payload,
+		Status:     webhookStatusFailed,
+		Error:      sql.NullString{String: "database unavailable", Valid: true},
+		Attempts:   1,
+		ReceivedAt: time.Now().UTC(),
+	}
+	db.webhookEvents = append(db.webhookEvents, failed)
+
+	replay := func() *httptest.ResponseRecorder {
+		req := newTestRequest(t, http.MethodPost, "/admin/webhooks/events/"+failed.ID.String()+"/replay", admin.ID, nil)
+		req.SetPathValue("eventID", failed.ID.String())
+		rec := httptest.NewRecorder()
+		cfg.handlerWebhookEventsReplay(rec, req)
+		return rec
+	}
+
+	rec := replay()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first replay: status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var event WebhookEvent
+	if err := json.NewDecoder(rec.Body).Decode(&event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Status != webhookStatusProcessed || event.Attempts != 2 {
+		t.Errorf("replayed event has status %q after %d attempts, want %q after 2", event.Status, event.Attempts, webhookStatusProcessed)
+	}
+
+	if rec := replay(); rec.Code != http.StatusConflict {
+		t.Errorf("second replay: status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+}
+
+func TestPolkaWebhookUnknownUser(t *testing.T) {
+	for _, event := range []string{polkaEventUserUpgraded, polkaEventUserDowngraded} {
+		t.Run(event, func(t *testing.T) {
+			db := newFakeDB()
+			cfg := newTestConfig(t, db)
+
+			rec := httptest.NewRecorder()
+			cfg.handlerPolkaWebhooks(rec, polkaRequest(t, "evt_unknown", event, uuid.New()))
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("status = %d, want %d: %s", rec.Code, http.StatusNotFound, rec.Body)
+			}
+			if len(db.subscriptions) != 0 {
+				t.Errorf("got %d subscriptions, want none", len(db.subscriptions))
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerRechirpsCreate(w http.ResponseWriter, req *http.Request) {
+	userID, chirp, ok := cfg.chirpTarget(w, req)
+	if !ok {
+		return
+	}
+	if !cfg.requireNotBlocked(w, req, chirp.UserID, userID) {
+		return
+	}
+
+	added, err := cfg.db.Rechirp(req.Context(), database.RechirpParams{
+		UserID:  userID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to rechirp", err)
+		return
+	}
+
+	if added > 0 {
+		cfg.notify(notificationJob{
+			Type:       notificationTypeRechirp,
+			ActorID:    userID,
+			Recipients: []uuid.UUID{chirp.UserID},
+			ChirpID:    chirp.ID,
+		})
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRechirpsDelete(w http.ResponseWriter, req *http.Request) {
+	userID, chirp, ok := cfg.chirpTarget(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.Unrechirp(req.Context(), database.UnrechirpParams{
+		UserID:  userID,
+		ChirpID: chirp.ID,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to undo rechirp", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
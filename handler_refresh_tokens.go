@@ -14,30 +14,38 @@ func (cfg *apiConfig) handlerRefreshTokensRefresh(w http.ResponseWriter, req *ht
 
 	token, err := auth.GetBearerToken(req.Header)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid header", err)
+		respondWithError(w, req, http.StatusBadRequest, "invalid header", err)
 		return
 	}
 
 	/*
 		refToken, err := cfg.db.GetRefreshToken(req.Context(), token)
 		if err != nil || refToken.RevokedAt.Valid || time.Now().UTC().After(refToken.ExpiresAt) {
-			respondWithError(w, http.StatusUnauthorized, "unauthorized access", err)
+			respondWithError(w, req, http.StatusUnauthorized, "unauthorized access", err)
 			return
 		}
 	*/
 
 	user, err := cfg.db.GetUserFromRefreshToken(req.Context(), token)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Couldn't get user for refresh token", err)
+		respondWithError(w, req, http.StatusUnauthorized, "Couldn't get user for refresh token", err)
+		return
+	}
+	if !requireActiveAccount(w, user) {
 		return
 	}
 
 	accessToken, err := auth.MakeJWT(user.ID, cfg.jwtSecret, time.Hour)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't validate token", err)
+		respondWithError(w, req, http.StatusInternalServerError, "Couldn't validate token", err)
 		return
 	}
 
+	event := cfg.newAuditEvent(req, auditTokenRefreshed)
+	event.ActorID = user.ID
+	event.TargetID = user.ID
+	cfg.audit(req.Context(), event)
+
 	resp := response{
 		Token: accessToken,
 	}
@@ -48,16 +56,21 @@ func (cfg *apiConfig) handlerRefreshTokensRefresh(w http.ResponseWriter, req *ht
 func (cfg *apiConfig) handlerRefreshTokensRevoke(w http.ResponseWriter, req *http.Request) {
 	token, err := auth.GetBearerToken(req.Header)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "invalid header", err)
+		respondWithError(w, req, http.StatusInternalServerError, "invalid header", err)
 		return
 	}
 
-	_, err = cfg.db.RevokeRefreshToken(req.Context(), token)
+	revoked, err := cfg.db.RevokeRefreshToken(req.Context(), token)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to revoke token", err)
+		respondWithError(w, req, http.StatusInternalServerError, "failed to revoke token", err)
 		return
 	}
 
+	event := cfg.newAuditEvent(req, auditTokenRevoked)
+	event.ActorID = revoked.UserID
+	event.TargetID = revoked.UserID
+	cfg.audit(req.Context(), event)
+
 	//respondWithJSON(w, http.StatusNoContent, nil)
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	reportStatusOpen      = "open"
+	reportStatusActioned  = "actioned"
+	reportStatusDismissed = "dismissed"
+
+	// reportReasonFiltered is used for reports filed by the content filter.
+	// Users can't choose it.
+	reportReasonFiltered = "filtered"
+
+	maxReportDetailsLength = 1000
+)
+
+var reportReasons = []string{
+	"spam",
+	"harassment",
+	"hate",
+	"violence",
+	"sexual",
+	"misinformation",
+	"self_harm",
+	"other",
+}
+
+type Report struct {
+	ID             uuid.UUID  `json:"id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ChirpID        *uuid.UUID `json:"chirp_id"`
+	ReportedUserID uuid.UUID  `json:"reported_user_id"`
+	ReporterID     *uuid.UUID `json:"reporter_id"`
+	Reason         string     `json:"reason"`
+	Details        string     `json:"details"`
+	Status         string     `json:"status"`
+	ResolvedBy     *uuid.UUID `json:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+}
+
+func (cfg *apiConfig) handlerReportsCreate(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+
+	userID, chirp, ok := cfg.chirpTarget(w, req)
+	if !ok {
+		return
+	}
+	if chirp.UserID == userID {
+		respondWithError(w, req, http.StatusBadRequest, "you can't report your own chirp", nil)
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(req.Body, &params)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "couldn't decode parameters", err)
+		return
+	}
+	if !slices.Contains(reportReasons, params.Reason) {
+		respondWithError(w, req, http.StatusBadRequest, "unknown report reason", nil)
+		return
+	}
+	if len(params.Details) > maxReportDetailsLength {
+		respondWithError(w, req, http.StatusBadRequest, "report details are too long", nil)
+		return
+	}
+
+	report, err := cfg.db.CreateReport(req.Context(), database.CreateReportParams{
+		ChirpID:        uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		ReportedUserID: chirp.UserID,
+		ReporterID:     uuid.NullUUID{UUID: userID, Valid: true},
+		Reason:         params.Reason,
+		Details:        params.Details,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, req, http.StatusConflict, "you've already reported this chirp", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't file report", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, mapReport(report))
+}
+
+func mapReport(r database.Report) Report {
+	report := Report{
+		ID:             r.ID,
+		CreatedAt:      r.CreatedAt,
+		ReportedUserID: r.ReportedUserID,
+		Reason:         r.Reason,
+		Details:        r.Details,
+		Status:         r.Status,
+	}
+	if r.ChirpID.Valid {
+		report.ChirpID = &r.ChirpID.UUID
+	}
+	if r.ReporterID.Valid {
+		report.ReporterID = &r.ReporterID.UUID
+	}
+	if r.ResolvedBy.Valid {
+		report.ResolvedBy = &r.ResolvedBy.UUID
+	}
+	if r.ResolvedAt.Valid {
+		report.ResolvedAt = &r.ResolvedAt.Time
+	}
+	return report
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReportsCreateRejectsDuplicates(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+	author := db.addUser(roleUser)
+	reporter := db.addUser(roleUser)
+	chirp := db.addChirp(author.ID, "hello", chirpVisibilityPublic)
+
+	tests := []struct {
+		name   string
+		reason string
+		want   int
+	}{
+		{"first report", "spam", http.StatusCreated},
+		{"second report", "harassment", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		req := newTestRequest(t, http.MethodPost, "/api/chirps/"+chirp.ID.String()+"/report", reporter.ID, map[string]string{"reason": tt.reason})
+		req.SetPathValue("chirpID", chirp.ID.String())
+		rec := httptest.NewRecorder()
+		cfg.handlerReportsCreate(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, rec.Code, tt.want, rec.Body)
+		}
+	}
+	if len(db.reports) != 1 {
+		t.Errorf("got %d reports, want 1", len(db.reports))
+	}
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
 	"time"
 
 	"github.com/CybrRonin/Chirpy/internal/auth"
 	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/logging"
 	"github.com/google/uuid"
 )
 
@@ -20,6 +22,10 @@ type User struct {
 	RefreshToken string       `json:"refresh_token"`
 	RevokedAt    sql.NullTime `json:"revoked_at"`
 	IsChirpyRed  bool         `json:"is_chirpy_red"`
+	Protected    bool         `json:"protected"`
+	// SuspendedUntil and BannedAt are only set while the account is restricted.
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+	BannedAt       *time.Time `json:"banned_at,omitempty"`
 }
 
 type userParameters struct {
@@ -32,13 +38,19 @@ func (cfg *apiConfig) handlerUsersCreate(w http.ResponseWriter, req *http.Reques
 
 	err := decodeJSON(req.Body, &params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "error decoding user's email or password", err)
+		respondWithError(w, req, http.StatusInternalServerError, "error decoding user's email or password", err)
 		return
 	}
 
-	hashedPwd, err := auth.HashPassword(params.Password)
+	err = cfg.passwordPolicy.Validate(params.Password)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "error hashing password", err)
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	hashedPwd, err := auth.HashPasswordWithParams(req.Context(), params.Password, cfg.hashParams)
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "error hashing password", err)
 		return
 	}
 
@@ -48,7 +60,7 @@ func (cfg *apiConfig) handlerUsersCreate(w http.ResponseWriter, req *http.Reques
 	}
 	u, err := cfg.db.CreateUser(req.Context(), dbParams)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to create user", err)
+		respondWithError(w, req, http.StatusInternalServerError, "failed to create user", err)
 		return
 	}
 
@@ -66,25 +78,35 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, req *http.Request) {
 
 	err := decodeJSON(req.Body, &params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to decode user parameters", err)
+		respondWithError(w, req, http.StatusInternalServerError, "failed to decode user parameters", err)
 		return
 	}
 
 	user, err := cfg.db.GetUserByEmail(req.Context(), params.Email)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
+		cfg.recordLoginFailure(req, uuid.Nil, params.Email, "unknown_email")
+		respondWithError(w, req, http.StatusUnauthorized, "Incorrect email or password", err)
 		return
 	}
 
-	match, err := auth.CheckPasswordHash(params.Password, user.HashedPassword)
+	match, err := auth.CheckPasswordHash(req.Context(), params.Password, user.HashedPassword)
 	if err != nil || !match {
-		respondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
+		cfg.recordLoginFailure(req, user.ID, params.Email, "wrong_password")
+		respondWithError(w, req, http.StatusUnauthorized, "Incorrect email or password", err)
+		return
+	}
+
+	if restriction, restricted := restrictionFor(user, time.Now().UTC()); restricted {
+		cfg.recordLoginFailure(req, user.ID, params.Email, restriction.Status)
+		respondWithJSON(w, http.StatusForbidden, restriction)
 		return
 	}
 
+	cfg.rehashPasswordIfNeeded(req.Context(), user, params.Password)
+
 	accessToken, err := auth.MakeJWT(user.ID, cfg.jwtSecret, defaultAccessExpiration)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to generate JWT", err)
+		respondWithError(w, req, http.StatusInternalServerError, "failed to generate JWT", err)
 		return
 	}
 
@@ -97,61 +119,183 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, req *http.Request) {
 	}
 	_, err = cfg.db.CreateRefreshToken(req.Context(), refreshArgs)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to create refresh token entry", err)
+		respondWithError(w, req, http.StatusInternalServerError, "failed to create refresh token entry", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, mapUser(user, accessToken, refreshToken))
+	cfg.metrics.ObserveLogin("success")
+
+	event := cfg.newAuditEvent(req, auditLoginSucceeded)
+	event.ActorID = user.ID
+	event.TargetID = user.ID
+	cfg.audit(req.Context(), event)
+
+	resp := mapUser(user, accessToken, refreshToken)
+	resp.IsChirpyRed = cfg.isChirpyRed(req.Context(), user.ID)
+	respondWithJSON(w, http.StatusOK, resp)
 }
 
-func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, req *http.Request) {
-	accessToken, err := auth.GetBearerToken(req.Header)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "couldn't find JWT", err)
-		return
+// recordLoginFailure audits and counts a failed login. userID is uuid.Nil
+// when no account has the given email.
+func (cfg *apiConfig) recordLoginFailure(req *http.Request, userID uuid.UUID, email, reason string) {
+	cfg.metrics.ObserveLogin(reason)
+
+	event := cfg.newAuditEvent(req, auditLoginFailed)
+	event.TargetID = userID
+	event.Payload = map[string]string{
+		"email":  email,
+		"reason": reason,
 	}
+	cfg.audit(req.Context(), event)
+}
 
-	accessID, err := auth.ValidateJWT(accessToken, cfg.jwtSecret)
-	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, "couldn't validate JWT", err)
+func (cfg *apiConfig) handlerUsersUpdate(w http.ResponseWriter, req *http.Request) {
+	account, ok := cfg.requireAccount(w, req)
+	if !ok {
 		return
 	}
 
 	userParams := userParameters{}
-	err = decodeJSON(req.Body, &userParams)
+	err := decodeJSON(req.Body, &userParams)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "unable to decode parameters", err)
+		respondWithError(w, req, http.StatusInternalServerError, "unable to decode parameters", err)
 		return
 	}
 
-	hashedPwd, err := auth.HashPassword(userParams.Password)
+	// Resubmitting the current password alongside a new email isn't a
+	// password change, so keep the existing hash and don't audit one. Nor
+	// hold it to a policy that may have tightened since it was set.
+	unchanged, err := auth.CheckPasswordHash(req.Context(), userParams.Password, account.HashedPassword)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to hash password", err)
+		respondWithError(w, req, http.StatusInternalServerError, "failed to check password", err)
 		return
 	}
 
+	hashedPwd := account.HashedPassword
+	if !unchanged {
+		err = cfg.passwordPolicy.Validate(userParams.Password)
+		if err != nil {
+			respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+
+		hashedPwd, err = auth.HashPasswordWithParams(req.Context(), userParams.Password, cfg.hashParams)
+		if err != nil {
+			respondWithError(w, req, http.StatusInternalServerError, "failed to hash password", err)
+			return
+		}
+	}
+
 	pwdArgs := database.UpdateUserParams{
-		ID:             accessID,
+		ID:             account.ID,
 		Email:          userParams.Email,
 		HashedPassword: hashedPwd,
 	}
 
 	user, err := cfg.db.UpdateUser(req.Context(), pwdArgs)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "error updating password", err)
+		respondWithError(w, req, http.StatusInternalServerError, "error updating password", err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, mapUser(user))
+	event := cfg.newAuditEvent(req, auditPasswordChanged)
+	event.ActorID = user.ID
+	event.TargetID = user.ID
+	if !unchanged {
+		cfg.audit(req.Context(), event)
+	}
+	if user.Email != account.Email {
+		event.Type = auditEmailChanged
+		event.Payload = map[string]string{
+			"old_email": account.Email,
+			"new_email": user.Email,
+		}
+		cfg.audit(req.Context(), event)
+	}
+
+	resp := mapUser(user)
+	resp.IsChirpyRed = cfg.isChirpyRed(req.Context(), user.ID)
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// handlerUsersPrivacy toggles whether the caller's account is protected.
+// Making an account public again approves every pending follow request.
+func (cfg *apiConfig) handlerUsersPrivacy(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		Protected bool `json:"protected"`
+	}
+
+	userID, ok := cfg.requireUser(w, req)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(req.Body, &params)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "couldn't decode parameters", err)
+		return
+	}
+
+	var user database.User
+	err = cfg.withTx(req.Context(), func(q database.Querier) error {
+		user, err = q.SetUserProtected(req.Context(), database.SetUserProtectedParams{
+			ID:        userID,
+			Protected: params.Protected,
+		})
+		if err != nil || params.Protected {
+			return err
+		}
+		_, err = q.AcceptAllFollowRequests(req.Context(), userID)
+		return err
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't update privacy settings", err)
+		return
+	}
+
+	resp := mapUser(user)
+	resp.IsChirpyRed = cfg.isChirpyRed(req.Context(), user.ID)
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// rehashPasswordIfNeeded upgrades a stored hash to the current argon2id
+// parameters. It only runs after a successful login, since that's the one
+// time the plaintext is available. Failures are logged but don't block the login.
+func (cfg *apiConfig) rehashPasswordIfNeeded(ctx context.Context, user database.User, password string) {
+	needsRehash, err := auth.NeedsRehash(user.HashedPassword, cfg.hashParams)
+	if err != nil || !needsRehash {
+		return
+	}
+
+	hashedPwd, err := auth.HashPasswordWithParams(ctx, password, cfg.hashParams)
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to rehash password", "user_id", user.ID, "error", err)
+		return
+	}
+
+	err = cfg.db.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+		ID:             user.ID,
+		HashedPassword: hashedPwd,
+	})
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to store rehashed password", "user_id", user.ID, "error", err)
+	}
 }
 
 func mapUser(user database.User, options ...string) User {
 	newUser := User{
-		ID:          user.ID,
-		CreatedAt:   user.CreatedAt,
-		UpdatedAt:   user.UpdatedAt,
-		Email:       user.Email,
-		IsChirpyRed: user.IsChirpyRed,
+		ID:        user.ID,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Email:     user.Email,
+		Protected: user.Protected,
+	}
+	if user.SuspendedUntil.Valid && user.SuspendedUntil.Time.After(time.Now()) {
+		newUser.SuspendedUntil = &user.SuspendedUntil.Time
+	}
+	if user.BannedAt.Valid {
+		newUser.BannedAt = &user.BannedAt.Time
 	}
 
 	if len(options) > 1 {
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CybrRonin/Chirpy/internal/auth"
+	"github.com/CybrRonin/Chirpy/internal/database"
+)
+
+func TestUsersUpdateOnlyAppliesPolicyToNewPasswords(t *testing.T) {
+	const oldPassword = "hunter2"
+
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+	cfg.hashParams = auth.HashParams{Memory: 1024, Iterations: 1, Parallelism: 1}
+	cfg.passwordPolicy = &auth.PasswordPolicy{MinLength: 12}
+
+	hash, err := auth.HashPasswordWithParams(context.Background(), oldPassword, cfg.hashParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := db.addUser(roleUser)
+	user, err = db.UpdateUser(context.Background(), database.UpdateUserParams{
+		ID:             user.ID,
+		Email:          user.Email,
+		HashedPassword: hash,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		password   string
+		wantStatus int
+	}{
+		{
+			name:       "Unchanged password predating the policy",
+			password:   oldPassword,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "New password failing the policy",
+			password:   "hunter3",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "New password meeting the policy",
+			password:   "correct horse battery staple",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestRequest(t, http.MethodPut, "/api/users", user.ID, map[string]string{
+				"email":    user.Email,
+				"password": tt.password,
+			})
+			rec := httptest.NewRecorder()
+			cfg.handlerUsersUpdate(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type WebhookEvent struct {
+	ID          uuid.UUID       `json:"id"`
+	Provider    string          `json:"provider"`
+	EventID     string          `json:"event_id,omitempty"`
+	EventType   string          `json:"event_type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Error       string          `json:"error,omitempty"`
+	Attempts    int32           `json:"attempts"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	ProcessedAt *time.Time      `json:"processed_at"`
+}
+
+func (cfg *apiConfig) handlerWebhookEventsList(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireRole(w, req, roleAdmin); !ok {
+		return
+	}
+
+	status := req.URL.Query().Get("status")
+	if status == "" {
+		status = webhookStatusFailed
+	}
+	limit, offset, err := paginationFromRequest(req)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	events, err := cfg.db.ListWebhookEventsByStatus(req.Context(), database.ListWebhookEventsByStatusParams{
+		Status: status,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to retrieve webhook events", err)
+		return
+	}
+
+	resp := []WebhookEvent{}
+	for _, event := range events {
+		resp = append(resp, mapWebhookEvent(event))
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerWebhookEventsReplay(w http.ResponseWriter, req *http.Request) {
+	if _, ok := cfg.requireRole(w, req, roleAdmin); !ok {
+		return
+	}
+
+	eventID, err := uuid.Parse(req.PathValue("eventID"))
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "invalid event ID", err)
+		return
+	}
+
+	event, duplicate, err := cfg.processWebhookEvent(req.Context(), func(q database.Querier) (database.WebhookEvent, error) {
+		return q.GetWebhookEventForUpdate(req.Context(), eventID)
+	})
+	if errors.Is(err, sql.ErrNoRows) && event.ID == uuid.Nil {
+		respondWithError(w, req, http.StatusNotFound, "couldn't retrieve webhook event", err)
+		return
+	}
+	if duplicate {
+		respondWithError(w, req, http.StatusConflict, "webhook event was already processed", nil)
+		return
+	}
+
+	// A failed replay is still reported with 200: the event's status and error say what happened.
+	if err != nil && event.Status != webhookStatusFailed {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to replay webhook event", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, mapWebhookEvent(event))
+}
+
+func mapWebhookEvent(event database.WebhookEvent) WebhookEvent {
+	resp := WebhookEvent{
+		ID:         event.ID,
+		Provider:   event.Provider,
+		EventID:    event.EventID.String,
+		EventType:  event.EventType,
+		Payload:    event.Payload,
+		Status:     event.Status,
+		Error:      event.Error.String,
+		Attempts:   event.Attempts,
+		ReceivedAt: event.ReceivedAt,
+	}
+	if event.ProcessedAt.Valid {
+		resp.ProcessedAt = &event.ProcessedAt.Time
+	}
+	return resp
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/config"
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+const minWebhookSecretLength = 16
+
+type WebhookSubscriber struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+}
+
+type WebhookDelivery struct {
+	ID                 uuid.UUID       `json:"id"`
+	CreatedAt          time.Time       `json:"created_at"`
+	EventType          string          `json:"event_type"`
+	Payload            json.RawMessage `json:"payload"`
+	Status             string          `json:"status"`
+	Attempts           int32           `json:"attempts"`
+	NextAttemptAt      time.Time       `json:"next_attempt_at"`
+	LastResponseStatus *int32          `json:"last_response_status"`
+	LastError          string          `json:"last_error,omitempty"`
+	DeliveredAt        *time.Time      `json:"delivered_at"`
+}
+
+func (cfg *apiConfig) handlerWebhookSubscribersCreate(w http.ResponseWriter, req *http.Request) {
+	type parameters struct {
+		URL        string   `json:"url"`
+		Secret     string   `json:"secret"`
+		EventTypes []string `json:"event_types"`
+	}
+
+	user, ok := cfg.requireRole(w, req, roleAdmin)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	err := decodeJSON(req.Body, &params)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "couldn't decode parameters", err)
+		return
+	}
+
+	err = cfg.validateWebhookSubscriber(req.Context(), params.URL, params.Secret, params.EventTypes)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	sub, err := cfg.db.CreateWebhookSubscriber(req.Context(), database.CreateWebhookSubscriberParams{
+		OwnerID:    user.ID,
+		Url:        params.URL,
+		Secret:     params.Secret,
+		EventTypes: params.EventTypes,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to create webhook subscriber", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, mapWebhookSubscriber(sub))
+}
+
+func (cfg *apiConfig) handlerWebhookSubscribersList(w http.ResponseWriter, req *http.Request) {
+	user, ok := cfg.requireRole(w, req, roleAdmin)
+	if !ok {
+		return
+	}
+
+	subs, err := cfg.db.ListWebhookSubscribersByOwner(req.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to retrieve webhook subscribers", err)
+		return
+	}
+
+	resp := []WebhookSubscriber{}
+	for _, sub := range subs {
+		resp = append(resp, mapWebhookSubscriber(sub))
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+func (cfg *apiConfig) handlerWebhookSubscribersDelete(w http.ResponseWriter, req *http.Request) {
+	sub, ok := cfg.ownedWebhookSubscriber(w, req)
+	if !ok {
+		return
+	}
+
+	err := cfg.db.DeleteWebhookSubscriber(req.Context(), sub.ID)
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to delete webhook subscriber", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerWebhookDeliveriesList(w http.ResponseWriter, req *http.Request) {
+	sub, ok := cfg.ownedWebhookSubscriber(w, req)
+	if !ok {
+		return
+	}
+
+	limit, offset, err := paginationFromRequest(req)
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	deliveries, err := cfg.db.ListWebhookDeliveriesBySubscriber(req.Context(), database.ListWebhookDeliveriesBySubscriberParams{
+		SubscriberID: sub.ID,
+		Limit:        limit,
+		Offset:       offset,
+	})
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "failed to retrieve webhook deliveries", err)
+		return
+	}
+
+	resp := []WebhookDelivery{}
+	for _, d := range deliveries {
+		resp = append(resp, mapWebhookDelivery(d))
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
+
+// ownedWebhookSubscriber loads the subscriber named in the path, checking that
+// it belongs to the caller.
+func (cfg *apiConfig) ownedWebhookSubscriber(w http.ResponseWriter, req *http.Request) (database.WebhookSubscriber, bool) {
+	user, ok := cfg.requireRole(w, req, roleAdmin)
+	if !ok {
+		return database.WebhookSubscriber{}, false
+	}
+
+	subID, err := uuid.Parse(req.PathValue("subscriberID"))
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "invalid subscriber ID", err)
+		return database.WebhookSubscriber{}, false
+	}
+
+	sub, err := cfg.db.GetWebhookSubscriber(req.Context(), subID)
+	if err != nil || sub.OwnerID != user.ID {
+		respondWithError(w, req, http.StatusNotFound, "webhook subscriber not found", err)
+		return database.WebhookSubscriber{}, false
+	}
+
+	return sub, true
+}
+
+// validateWebhookSubscriber checks a subscriber's settings. Outside dev the
+// URL's host must resolve only to public addresses.
+func (cfg *apiConfig) validateWebhookSubscriber(ctx context.Context, rawURL, secret string, eventTypes []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if cfg.platform != config.PlatformDev {
+		err = webhooks.CheckHost(ctx, u.Hostname())
+		if errors.Is(err, webhooks.ErrPrivateAddress) {
+			return errors.New("url must not point at a private or loopback address")
+		}
+		if err != nil {
+			return errors.New("url host couldn't be resolved")
+		}
+	}
+	if len(secret) < minWebhookSecretLength {
+		return errors.New("secret must be at least 16 characters")
+	}
+	if len(eventTypes) == 0 {
+		return errors.New("at least one event type is required")
+	}
+	for _, eventType := range eventTypes {
+		if !slices.Contains(webhooks.EventTypes, eventType) {
+			return errors.New("unknown event type: " + eventType)
+		}
+	}
+	return nil
+}
+
+func mapWebhookSubscriber(sub database.WebhookSubscriber) WebhookSubscriber {
+	return WebhookSubscriber{
+		ID:         sub.ID,
+		CreatedAt:  sub.CreatedAt,
+		URL:        sub.Url,
+		EventTypes: sub.EventTypes,
+		Active:     sub.Active,
+	}
+}
+
+func mapWebhookDelivery(d database.WebhookDelivery) WebhookDelivery {
+	resp := WebhookDelivery{
+		ID:            d.ID,
+		CreatedAt:     d.CreatedAt,
+		EventType:     d.EventType,
+		Payload:       d.Payload,
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		NextAttemptAt: d.NextAttemptAt,
+		LastError:     d.LastError.String,
+	}
+	if d.LastResponseStatus.Valid {
+		resp.LastResponseStatus = &d.LastResponseStatus.Int32
+	}
+	if d.DeliveredAt.Valid {
+		resp.DeliveredAt = &d.DeliveredAt.Time
+	}
+	return resp
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookSubscribersCreateRejectsPrivateAddresses(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+	admin := db.addUser(roleAdmin)
+
+	for _, rawURL := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"https://10.0.0.5/hook",
+	} {
+		t.Run(rawURL, func(t *testing.T) {
+			req := newTestRequest(t, http.MethodPost, "/api/webhooks/subscribers", admin.ID, map[string]any{
+				"url":         rawURL,
+				"secret":      "subscriber-secret",
+				"event_types": []string{"chirp.created"},
+			})
+			rec := httptest.NewRecorder()
+			cfg.handlerWebhookSubscribersCreate(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+			}
+		})
+	}
+}
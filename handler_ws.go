@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/auth"
+	"github.com/CybrRonin/Chirpy/internal/logging"
+	"github.com/CybrRonin/Chirpy/internal/realtime"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/google/uuid"
+)
+
+const (
+	wsHeartbeatInterval = 30 * time.Second
+	// wsAccountCheckInterval is how often an open connection checks that its
+	// account hasn't since been suspended or banned.
+	wsAccountCheckInterval = time.Minute
+	wsWriteTimeout         = 10 * time.Second
+	wsReadLimit            = 4096
+
+	wsMessageSubscribe   = "subscribe"
+	wsMessageUnsubscribe = "unsubscribe"
+	wsMessageSubscribed  = "subscribed"
+	wsMessageEvent       = "event"
+	wsMessageError       = "error"
+
+	// wsTopicNotifications is how clients ask for their own notifications.
+	wsTopicNotifications = "notifications"
+)
+
+type wsMessage struct {
+	Type   string          `json:"type"`
+	Topics []string        `json:"topics,omitempty"`
+	Event  *realtime.Event `json:"event,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type wsClient struct {
+	userID uuid.UUID
+
+	mu     sync.Mutex
+	topics map[string]struct{}
+}
+
+func (c *wsClient) wants(e realtime.Event) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, topic := range e.Topics {
+		if _, found := c.topics[topic]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveTopic validates a topic requested by the client and returns the
+// topic events are published under.
+func (c *wsClient) resolveTopic(topic string) (string, error) {
+	if topic == realtime.TopicFeed {
+		return topic, nil
+	}
+	if topic == wsTopicNotifications {
+		return realtime.NotificationsTopic(c.userID), nil
+	}
+
+	kind, rawID, _ := strings.Cut(topic, ":")
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return "", fmt.Errorf("invalid topic: %s", topic)
+	}
+	switch kind {
+	case "user":
+		return realtime.UserTopic(id), nil
+	case "thread":
+		return realtime.ThreadTopic(id), nil
+	}
+	return "", fmt.Errorf("unknown topic: %s", topic)
+}
+
+// handlerWebSocket serves the realtime API. Clients send subscribe and
+// unsubscribe messages naming topics, and receive an event message for every
+// matching event. A client that can't keep up is disconnected rather than
+// allowed to hold up publishers.
+func (cfg *apiConfig) handlerWebSocket(w http.ResponseWriter, req *http.Request) {
+	// Browsers can't set headers on WebSocket requests, so the token may also
+	// come from the query string.
+	token, err := auth.GetBearerToken(req.Header)
+	if err != nil {
+		token = req.URL.Query().Get("token")
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, req, http.StatusUnauthorized, "Couldn't validate JWT", err)
+		return
+	}
+	if _, ok := cfg.activeAccount(w, req, userID); !ok {
+		return
+	}
+
+	// Clear the server's request timeouts before the connection is hijacked;
+	// heartbeats and wsWriteTimeout take over from here.
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	conn, err := websocket.Accept(w, req, nil)
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(wsReadLimit)
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	client := &wsClient{
+		userID: userID,
+		topics: map[string]struct{}{},
+	}
+	sub, _ := cfg.hub.Subscribe(0, client.wants)
+	defer sub.Close()
+
+	go func() {
+		defer cancel()
+		cfg.wsReadLoop(ctx, conn, client)
+	}()
+
+	heartbeat := time.NewTicker(wsHeartbeatInterval)
+	defer heartbeat.Stop()
+	accountCheck := time.NewTicker(wsAccountCheckInterval)
+	defer accountCheck.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			conn.Close(websocket.StatusNormalClosure, "")
+			return
+		case <-cfg.streamsClosed:
+			conn.Close(websocket.StatusGoingAway, "server shutting down")
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				conn.Close(websocket.StatusTryAgainLater, "client fell too far behind")
+				return
+			}
+			err = wsWrite(ctx, conn, wsMessage{Type: wsMessageEvent, Event: &e})
+		case <-heartbeat.C:
+			pingCtx, cancelPing := context.WithTimeout(ctx, wsWriteTimeout)
+			err = conn.Ping(pingCtx)
+			cancelPing()
+		case <-accountCheck.C:
+			if reason, revoked := cfg.wsAccessRevoked(ctx, userID); revoked {
+				conn.Close(websocket.StatusPolicyViolation, reason)
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// wsAccessRevoked reports whether userID's account has been suspended, banned
+// or deleted since its connection was opened, and why. A failed lookup keeps
+// the connection open; the next check will try again.
+func (cfg *apiConfig) wsAccessRevoked(ctx context.Context, userID uuid.UUID) (string, bool) {
+	user, err := cfg.db.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "account no longer exists", true
+	}
+	if err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "failed to recheck websocket account", "user_id", userID, "error", err)
+		return "", false
+	}
+	restriction, restricted := restrictionFor(user, time.Now().UTC())
+	return restriction.Error, restricted
+}
+
+func (cfg *apiConfig) wsReadLoop(ctx context.Context, conn *websocket.Conn, client *wsClient) {
+	for {
+		_, data, err := conn.Read(ctx)
+		if err != nil {
+			return
+		}
+
+		msg := wsMessage{}
+		err = json.Unmarshal(data, &msg)
+		if err != nil {
+			err = wsWrite(ctx, conn, wsMessage{Type: wsMessageError, Error: "couldn't decode message"})
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		topics := make([]string, 0, len(msg.Topics))
+		for _, requested := range msg.Topics {
+			topic, err := client.resolveTopic(requested)
+			if err != nil {
+				wsWrite(ctx, conn, wsMessage{Type: wsMessageError, Error: err.Error()})
+				continue
+			}
+			topics = append(topics, topic)
+		}
+
+		client.mu.Lock()
+		switch msg.Type {
+		case wsMessageSubscribe:
+			for _, topic := range topics {
+				client.topics[topic] = struct{}{}
+			}
+		case wsMessageUnsubscribe:
+			for _, topic := range topics {
+				delete(client.topics, topic)
+			}
+		}
+		current := make([]string, 0, len(client.topics))
+		for topic := range client.topics {
+			current = append(current, topic)
+		}
+		client.mu.Unlock()
+
+		switch msg.Type {
+		case wsMessageSubscribe, wsMessageUnsubscribe:
+			err = wsWrite(ctx, conn, wsMessage{Type: wsMessageSubscribed, Topics: current})
+		default:
+			err = wsWrite(ctx, conn, wsMessage{Type: wsMessageError, Error: "unknown message type: " + msg.Type})
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func wsWrite(ctx context.Context, conn *websocket.Conn, msg wsMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, wsWriteTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, conn, msg)
+}
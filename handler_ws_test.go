@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestWSAccessRevoked(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+	ctx := context.Background()
+
+	active := db.addUser(roleUser)
+	suspended := db.addUser(roleUser)
+	_, err := db.SuspendUser(ctx, database.SuspendUserParams{
+		ID:             suspended.ID,
+		SuspendedUntil: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	banned := db.addUser(roleUser)
+	_, err = db.BanUser(ctx, database.BanUserParams{ID: banned.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		userID uuid.UUID
+		want   bool
+	}{
+		{name: "Active account", userID: active.ID, want: false},
+		{name: "Suspended since connecting", userID: suspended.ID, want: true},
+		{name: "Banned since connecting", userID: banned.ID, want: true},
+		{name: "Deleted since connecting", userID: uuid.New(), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, revoked := cfg.wsAccessRevoked(ctx, tt.userID)
+			if revoked != tt.want {
+				t.Errorf("wsAccessRevoked() = %v, want %v", revoked, tt.want)
+			}
+			if revoked && reason == "" {
+				t.Error("wsAccessRevoked() gave no reason")
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
@@ -12,8 +13,12 @@ import (
 	"github.com/alexedwards/argon2id"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 )
 
+var tracer = otel.Tracer("github.com/CybrRonin/Chirpy/internal/auth")
+
 type TokenType string
 
 const (
@@ -27,8 +32,18 @@ func HashPassword(password string) (string, error) {
 	return argon2id.CreateHash(password, argon2id.DefaultParams)
 }
 
-func CheckPasswordHash(password, hash string) (bool, error) {
-	return argon2id.ComparePasswordAndHash(password, hash)
+// CheckPasswordHash compares password with an argon2id hash. The comparison
+// is deliberately slow, so it gets its own span.
+func CheckPasswordHash(ctx context.Context, password, hash string) (bool, error) {
+	_, span := tracer.Start(ctx, "argon2id.compare")
+	defer span.End()
+
+	match, err := argon2id.ComparePasswordAndHash(password, hash)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return match, err
 }
 
 func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
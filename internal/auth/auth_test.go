@@ -1,7 +1,11 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -61,7 +65,7 @@ func TestCheckPasswordHash(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			match, err := CheckPasswordHash(tt.password, tt.hash)
+			match, err := CheckPasswordHash(context.Background(), tt.password, tt.hash)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CheckPasswordHash() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -164,3 +168,170 @@ func TestGetBearerToken(t *testing.T) {
 		})
 	}
 }
+
+func TestNeedsRehash(t *testing.T) {
+	current := HashParams{Memory: 16 * 1024, Iterations: 2, Parallelism: 1}
+	outdated := HashParams{Memory: 8 * 1024, Iterations: 1, Parallelism: 1}
+	currentHash, _ := HashPasswordWithParams(context.Background(), "correctPassword123!", current)
+	outdatedHash, _ := HashPasswordWithParams(context.Background(), "correctPassword123!", outdated)
+
+	tests := []struct {
+		name       string
+		hash       string
+		wantRehash bool
+		wantErr    bool
+	}{
+		{
+			name:       "Hash with current params",
+			hash:       currentHash,
+			wantRehash: false,
+			wantErr:    false,
+		},
+		{
+			name:       "Hash with outdated params",
+			hash:       outdatedHash,
+			wantRehash: true,
+			wantErr:    false,
+		},
+		{
+			name:       "Invalid hash",
+			hash:       "invalidhash",
+			wantRehash: false,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotRehash, err := NeedsRehash(tt.hash, current)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NeedsRehash() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotRehash != tt.wantRehash {
+				t.Errorf("NeedsRehash() = %v, want %v", gotRehash, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	blocklistPath := filepath.Join(t.TempDir(), "blocklist.txt")
+	err := os.WriteFile(blocklistPath, []byte("# common passwords\npassword123\n\nQwertyuiop\n"), 0o600)
+	if err != nil {
+		t.Fatalf("couldn't write blocklist: %v", err)
+	}
+	policy, err := NewPasswordPolicy(8, blocklistPath)
+	if err != nil {
+		t.Fatalf("NewPasswordPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "Acceptable password",
+			password: "correctPassword123!",
+			wantErr:  nil,
+		},
+		{
+			name:     "Too short",
+			password: "short",
+			wantErr:  ErrPasswordTooShort,
+		},
+		{
+			name:     "Blocklisted password",
+			password: "password123",
+			wantErr:  ErrPasswordBlocklisted,
+		},
+		{
+			name:     "Blocklist is case-insensitive",
+			password: "qwertyUIOP",
+			wantErr:  ErrPasswordBlocklisted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	body := []byte(`{"event":"user.upgraded","data":{"user_id":"3311741c-680c-4546-99f3-fc9efac2036c"}}`)
+	now := time.Now()
+	tolerance := 5 * time.Minute
+
+	tests := []struct {
+		name    string
+		header  string
+		body    []byte
+		secrets []string
+		wantErr error
+	}{
+		{
+			name:    "Valid signature",
+			header:  SignWebhookPayload(body, "current", now),
+			body:    body,
+			secrets: []string{"current"},
+			wantErr: nil,
+		},
+		{
+			name:    "Signed with a secret being rotated out",
+			header:  SignWebhookPayload(body, "previous", now),
+			body:    body,
+			secrets: []string{"current", "previous"},
+			wantErr: nil,
+		},
+		{
+			name:    "Wrong secret",
+			header:  SignWebhookPayload(body, "wrong", now),
+			body:    body,
+			secrets: []string{"current"},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "Tampered body",
+			header:  SignWebhookPayload(body, "current", now),
+			body:    []byte(`{"event":"user.upgraded","data":{"user_id":"00000000-0000-0000-0000-000000000000"}}`),
+			secrets: []string{"current"},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "Replayed outside the tolerance window",
+			header:  SignWebhookPayload(body, "current", now.Add(-time.Hour)),
+			body:    body,
+			secrets: []string{"current"},
+			wantErr: ErrSignatureExpired,
+		},
+		{
+			name:    "Missing header",
+			header:  "",
+			body:    body,
+			secrets: []string{"current"},
+			wantErr: ErrMissingSignature,
+		},
+		{
+			name:    "Malformed header",
+			header:  "v1=not-hex",
+			body:    body,
+			secrets: []string{"current"},
+			wantErr: ErrMalformedSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWebhookSignature(tt.header, tt.body, tt.secrets, tolerance, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyWebhookSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
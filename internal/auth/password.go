@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alexedwards/argon2id"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HashParams are the tunable argon2id cost parameters. Salt and key lengths
+// always use the library defaults.
+type HashParams struct {
+	Memory      uint32 // in KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
+var DefaultHashParams = HashParams{
+	Memory:      argon2id.DefaultParams.Memory,
+	Iterations:  argon2id.DefaultParams.Iterations,
+	Parallelism: argon2id.DefaultParams.Parallelism,
+}
+
+func (p HashParams) argon2Params() *argon2id.Params {
+	return &argon2id.Params{
+		Memory:      p.Memory,
+		Iterations:  p.Iterations,
+		Parallelism: p.Parallelism,
+		SaltLength:  argon2id.DefaultParams.SaltLength,
+		KeyLength:   argon2id.DefaultParams.KeyLength,
+	}
+}
+
+// HashPasswordWithParams hashes password with argon2id. Hashing is slow by
+// design, so it gets its own span carrying the cost parameters.
+func HashPasswordWithParams(ctx context.Context, password string, params HashParams) (string, error) {
+	_, span := tracer.Start(ctx, "argon2id.hash", trace.WithAttributes(
+		attribute.Int("argon2id.memory_kib", int(params.Memory)),
+		attribute.Int("argon2id.iterations", int(params.Iterations)),
+		attribute.Int("argon2id.parallelism", int(params.Parallelism)),
+	))
+	defer span.End()
+
+	hash, err := argon2id.CreateHash(password, params.argon2Params())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return hash, err
+}
+
+// NeedsRehash reports whether hash was created with parameters other than params,
+// meaning it should be replaced the next time the plaintext password is known.
+func NeedsRehash(hash string, params HashParams) (bool, error) {
+	current, _, _, err := argon2id.DecodeHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	want := params.argon2Params()
+	return current.Memory != want.Memory ||
+		current.Iterations != want.Iterations ||
+		current.Parallelism != want.Parallelism ||
+		current.KeyLength != want.KeyLength, nil
+}
+
+var (
+	ErrPasswordTooShort    = errors.New("password is too short")
+	ErrPasswordBlocklisted = errors.New("password is too common or has appeared in a breach")
+)
+
+type PasswordPolicy struct {
+	MinLength int
+	blocklist map[string]struct{}
+}
+
+// NewPasswordPolicy builds a policy from a minimum length and an optional
+// blocklist file containing one password per line. Blank lines and lines
+// starting with '#' are ignored.
+func NewPasswordPolicy(minLength int, blocklistPath string) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{
+		MinLength: minLength,
+		blocklist: map[string]struct{}{},
+	}
+	if blocklistPath == "" {
+		return policy, nil
+	}
+
+	f, err := os.Open(blocklistPath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open password blocklist: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		policy.blocklist[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read password blocklist: %w", err)
+	}
+
+	return policy, nil
+}
+
+func (p *PasswordPolicy) Validate(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrPasswordTooShort, p.MinLength)
+	}
+	if _, found := p.blocklist[strings.ToLower(password)]; found {
+		return ErrPasswordBlocklisted
+	}
+	return nil
+}
@@ -0,0 +1,393 @@
+// Package config loads and validates the server's settings.
+//
+// Every setting is named by an environment variable. Values are taken from,
+// in order of precedence, the process environment, a .env file in the working
+// directory, and the YAML or TOML file named by CONFIG_FILE, whose keys are
+// the variable names in lower case. Anything left unset gets its default.
+// Secrets can instead be read from a file by setting e.g. JWT_SECRET_FILE.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/auth"
+	"github.com/CybrRonin/Chirpy/internal/logging"
+	"github.com/CybrRonin/Chirpy/internal/ratelimit"
+	"github.com/CybrRonin/Chirpy/internal/tracing"
+	"github.com/joho/godotenv"
+)
+
+const (
+	PlatformDev        = "dev"
+	PlatformStaging    = "staging"
+	PlatformProduction = "production"
+)
+
+// MinJWTSecretLength is the shortest JWT_SECRET accepted. HS256 keys shorter
+// than the hash output make brute-forcing tokens offline practical.
+const MinJWTSecretLength = 32
+
+const (
+	defaultPort                    = 8080
+	defaultMinPasswordLength       = 8
+	defaultPolkaSignatureTolerance = 5 * time.Minute
+	defaultRateLimitRoutes         = "POST /api/chirps=30/1m,POST /api/login=10/1m"
+	defaultContentFilterReload     = 30 * time.Second
+	defaultChirpRestoreWindow      = 7 * 24 * time.Hour
+	defaultChirpPurgeAfterDays     = 30
+	defaultReadTimeout             = 15 * time.Second
+	defaultReadHeaderTimeout       = 5 * time.Second
+	defaultWriteTimeout            = 30 * time.Second
+	defaultIdleTimeout             = 2 * time.Minute
+	defaultMaxHeaderBytes          = 64 << 10
+	defaultShutdownTimeout         = 30 * time.Second
+	defaultTracingServiceName      = "chirpy"
+)
+
+type Config struct {
+	Port         int
+	Platform     string
+	LogLevel     slog.Level
+	DatabaseURL  string
+	JWTSecret    string
+	TrustProxy   bool
+	MetricsToken string
+
+	Polka         Polka
+	Passwords     Passwords
+	RateLimit     RateLimit
+	ContentFilter ContentFilter
+	Chirps        Chirps
+	// RealtimeBackend is "memory" or "postgres".
+	RealtimeBackend string
+	Tracing         tracing.Config
+	Server          Server
+}
+
+type Polka struct {
+	WebhookSecrets []string
+	// Key is the legacy API key, accepted alongside signed webhooks while
+	// AllowLegacyKey is set.
+	Key                string
+	AllowLegacyKey     bool
+	SignatureTolerance time.Duration
+}
+
+type Passwords struct {
+	Hash          auth.HashParams
+	MinLength     int
+	BlocklistFile string
+}
+
+type RateLimit struct {
+	// Backend is "memory" or "postgres".
+	Backend string
+	Default ratelimit.Limit
+	Routes  map[string]ratelimit.Limit
+}
+
+type ContentFilter struct {
+	// Backend is "static", "file" or "postgres".
+	Backend        string
+	File           string
+	ReloadInterval time.Duration
+}
+
+type Chirps struct {
+	RestoreWindow time.Duration
+	PurgeAfter    time.Duration
+}
+
+type Server struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	// ShutdownDelay keeps serving after readiness starts failing, giving
+	// load balancers time to notice before the listener closes.
+	ShutdownDelay time.Duration
+	// ShutdownTimeout bounds how long in-flight requests get to finish.
+	ShutdownTimeout time.Duration
+}
+
+// Load reads the configuration from the process environment, .env and
+// CONFIG_FILE. Every problem found is reported in the returned error, not
+// just the first.
+//
+// .env is loaded into the process environment without overriding variables
+// that are already set, so libraries that read the environment themselves,
+// like the OTLP exporter, see it too.
+func Load() (*Config, error) {
+	err := godotenv.Load()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("couldn't read .env: %w", err)
+	}
+	return load(os.LookupEnv)
+}
+
+func load(env func(string) (string, bool)) (*Config, error) {
+	src, err := newSource(env)
+	if err != nil {
+		return nil, err
+	}
+	l := &loader{src: src}
+
+	cfg := &Config{
+		Port:            l.int("PORT", defaultPort),
+		Platform:        l.string("PLATFORM", ""),
+		DatabaseURL:     l.secret("DB_URL"),
+		JWTSecret:       l.secret("JWT_SECRET"),
+		TrustProxy:      l.bool("TRUST_PROXY", false),
+		MetricsToken:    l.secret("METRICS_TOKEN"),
+		RealtimeBackend: l.oneOf("REALTIME_BACKEND", "memory", "postgres"),
+	}
+
+	cfg.LogLevel, err = logging.ParseLevel(l.string("LOG_LEVEL", ""))
+	if err != nil {
+		l.errorf("LOG_LEVEL", "%v", err)
+	}
+
+	if cfg.Port < 1 || cfg.Port > 65535 {
+		l.errorf("PORT", "must be between 1 and 65535")
+	}
+	switch cfg.Platform {
+	case "":
+		l.errorf("PLATFORM", "must be set")
+	case PlatformDev, PlatformStaging, PlatformProduction:
+	default:
+		l.errorf("PLATFORM", "must be one of %s, %s or %s, got %q", PlatformDev, PlatformStaging, PlatformProduction, cfg.Platform)
+	}
+	if cfg.DatabaseURL == "" {
+		l.errorf("DB_URL", "must be set")
+	}
+	if len(cfg.JWTSecret) < MinJWTSecretLength {
+		l.errorf("JWT_SECRET", "must be at least %d bytes", MinJWTSecretLength)
+	}
+
+	cfg.Polka = Polka{
+		WebhookSecrets:     splitList(l.secret("POLKA_WEBHOOK_SECRETS")),
+		Key:                l.secret("POLKA_KEY"),
+		AllowLegacyKey:     l.bool("POLKA_ALLOW_LEGACY_KEY", true),
+		SignatureTolerance: l.duration("POLKA_SIGNATURE_TOLERANCE", defaultPolkaSignatureTolerance),
+	}
+	if len(cfg.Polka.WebhookSecrets) == 0 && (cfg.Polka.Key == "" || !cfg.Polka.AllowLegacyKey) {
+		l.errorf("POLKA_WEBHOOK_SECRETS", "must be set unless POLKA_KEY is set and POLKA_ALLOW_LEGACY_KEY isn't false")
+	}
+
+	cfg.Passwords = Passwords{
+		Hash: auth.HashParams{
+			Memory:      uint32(l.uint("ARGON2_MEMORY", 32, uint64(auth.DefaultHashParams.Memory))),
+			Iterations:  uint32(l.uint("ARGON2_ITERATIONS", 32, uint64(auth.DefaultHashParams.Iterations))),
+			Parallelism: uint8(l.uint("ARGON2_PARALLELISM", 8, uint64(auth.DefaultHashParams.Parallelism))),
+		},
+		MinLength:     l.int("PASSWORD_MIN_LENGTH", defaultMinPasswordLength),
+		BlocklistFile: l.string("PASSWORD_BLOCKLIST_FILE", ""),
+	}
+	// argon2 panics on zero iterations or threads, which would take down
+	// every signup and login instead of failing here.
+	hash := cfg.Passwords.Hash
+	if hash.Iterations < 1 {
+		l.errorf("ARGON2_ITERATIONS", "must be at least 1")
+	}
+	if hash.Parallelism < 1 {
+		l.errorf("ARGON2_PARALLELISM", "must be at least 1")
+	}
+	if hash.Memory < 8*uint32(hash.Parallelism) {
+		l.errorf("ARGON2_MEMORY", "must be at least 8 KiB per thread, %d for ARGON2_PARALLELISM=%d", 8*uint32(hash.Parallelism), hash.Parallelism)
+	}
+
+	cfg.RateLimit.Backend = l.oneOf("RATE_LIMIT_BACKEND", "memory", "postgres")
+	if v := l.string("RATE_LIMIT_DEFAULT", ""); v != "" {
+		cfg.RateLimit.Default, err = ratelimit.ParseLimit(v)
+		if err != nil {
+			l.errorf("RATE_LIMIT_DEFAULT", "%v", err)
+		}
+	}
+	// An empty RATE_LIMIT_ROUTES turns the default route limits off.
+	routes, ok := src.lookup("RATE_LIMIT_ROUTES")
+	if !ok {
+		routes = defaultRateLimitRoutes
+	}
+	cfg.RateLimit.Routes, err = ratelimit.ParseRouteLimits(routes)
+	if err != nil {
+		l.errorf("RATE_LIMIT_ROUTES", "%v", err)
+	}
+
+	cfg.ContentFilter = ContentFilter{
+		Backend:        l.oneOf("CONTENT_FILTER_BACKEND", "static", "file", "postgres"),
+		File:           l.string("CONTENT_FILTER_FILE", ""),
+		ReloadInterval: l.positiveDuration("CONTENT_FILTER_RELOAD_INTERVAL", defaultContentFilterReload),
+	}
+	if cfg.ContentFilter.Backend == "file" && cfg.ContentFilter.File == "" {
+		l.errorf("CONTENT_FILTER_FILE", "must be set when CONTENT_FILTER_BACKEND is file")
+	}
+
+	purgeAfterDays := l.int("CHIRP_PURGE_AFTER_DAYS", defaultChirpPurgeAfterDays)
+	if purgeAfterDays <= 0 {
+		l.errorf("CHIRP_PURGE_AFTER_DAYS", "must be positive")
+	}
+	cfg.Chirps = Chirps{
+		RestoreWindow: l.positiveDuration("CHIRP_RESTORE_WINDOW", defaultChirpRestoreWindow),
+		PurgeAfter:    time.Duration(purgeAfterDays) * 24 * time.Hour,
+	}
+	if cfg.Chirps.RestoreWindow > cfg.Chirps.PurgeAfter {
+		l.errorf("CHIRP_RESTORE_WINDOW", "can't be longer than CHIRP_PURGE_AFTER_DAYS")
+	}
+
+	cfg.Tracing = tracing.Config{
+		Exporter:    l.oneOf("TRACING_EXPORTER", tracing.ExporterNone, tracing.ExporterOTLP),
+		ServiceName: defaultTracingServiceName,
+		SampleRatio: l.float("TRACING_SAMPLE_RATIO", 1),
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		l.errorf("TRACING_SAMPLE_RATIO", "must be between 0 and 1")
+	}
+
+	cfg.Server = Server{
+		ReadTimeout:       l.duration("HTTP_READ_TIMEOUT", defaultReadTimeout),
+		ReadHeaderTimeout: l.duration("HTTP_READ_HEADER_TIMEOUT", defaultReadHeaderTimeout),
+		WriteTimeout:      l.duration("HTTP_WRITE_TIMEOUT", defaultWriteTimeout),
+		IdleTimeout:       l.duration("HTTP_IDLE_TIMEOUT", defaultIdleTimeout),
+		MaxHeaderBytes:    l.int("HTTP_MAX_HEADER_BYTES", defaultMaxHeaderBytes),
+		ShutdownDelay:     l.duration("SHUTDOWN_DELAY", 0),
+		ShutdownTimeout:   l.duration("SHUTDOWN_TIMEOUT", defaultShutdownTimeout),
+	}
+	if cfg.Server.MaxHeaderBytes <= 0 {
+		l.errorf("HTTP_MAX_HEADER_BYTES", "must be positive")
+	}
+
+	if len(l.errs) > 0 {
+		return nil, errors.Join(l.errs...)
+	}
+	return cfg, nil
+}
+
+// loader parses values from a source, collecting errors instead of stopping
+// at the first one. Empty values are treated as unset.
+type loader struct {
+	src  *source
+	errs []error
+}
+
+func (l *loader) errorf(key, format string, args ...any) {
+	l.errs = append(l.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+func (l *loader) string(key, def string) string {
+	v, _ := l.src.lookup(key)
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func (l *loader) secret(key string) string {
+	v, err := l.src.lookupSecret(key)
+	if err != nil {
+		l.errorf(key, "%v", err)
+	}
+	return v
+}
+
+// oneOf returns the value of key, or the first allowed value if it's unset.
+func (l *loader) oneOf(key string, allowed ...string) string {
+	v := l.string(key, allowed[0])
+	if !slices.Contains(allowed, v) {
+		l.errorf(key, "must be one of %s, got %q", strings.Join(allowed, ", "), v)
+	}
+	return v
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (l *loader) bool(key string, def bool) bool {
+	v := l.string(key, "")
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.errorf(key, "must be true or false, got %q", v)
+		return def
+	}
+	return b
+}
+
+func (l *loader) int(key string, def int) int {
+	v := l.string(key, "")
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		l.errorf(key, "must be an integer, got %q", v)
+		return def
+	}
+	return n
+}
+
+func (l *loader) uint(key string, bitSize int, def uint64) uint64 {
+	v := l.string(key, "")
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseUint(v, 10, bitSize)
+	if err != nil {
+		l.errorf(key, "must be a non-negative %d-bit integer, got %q", bitSize, v)
+		return def
+	}
+	return n
+}
+
+func (l *loader) float(key string, def float64) float64 {
+	v := l.string(key, "")
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		l.errorf(key, "must be a number, got %q", v)
+		return def
+	}
+	return f
+}
+
+func (l *loader) duration(key string, def time.Duration) time.Duration {
+	v := l.string(key, "")
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		l.errorf(key, "must be a non-negative duration such as 30s, got %q", v)
+		return def
+	}
+	return d
+}
+
+func (l *loader) positiveDuration(key string, def time.Duration) time.Duration {
+	d := l.duration(key, def)
+	if d == 0 {
+		l.errorf(key, "must be positive")
+		return def
+	}
+	return d
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+func envFrom(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(contents), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := load(envFrom(map[string]string{
+		"PLATFORM":   PlatformDev,
+		"DB_URL":     "postgres://localhost/chirpy",
+		"JWT_SECRET": testJWTSecret,
+		"POLKA_KEY":  "polka",
+	}))
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+
+	if cfg.Port != defaultPort {
+		t.Errorf("Port = %d, want %d", cfg.Port, defaultPort)
+	}
+	if cfg.RateLimit.Backend != "memory" || len(cfg.RateLimit.Routes) != 2 {
+		t.Errorf("RateLimit = %+v, want the memory backend with the default routes", cfg.RateLimit)
+	}
+	if cfg.Chirps.PurgeAfter != defaultChirpPurgeAfterDays*24*time.Hour {
+		t.Errorf("Chirps.PurgeAfter = %v", cfg.Chirps.PurgeAfter)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+	}{
+		{
+			name: "yaml",
+			file: "chirpy.yaml",
+			contents: `platform: production
+port: 9000
+db_url: postgres://file/chirpy
+polka_webhook_secrets: [one, two]
+`,
+		},
+		{
+			name: "toml",
+			file: "chirpy.toml",
+			contents: `platform = "production"
+port = 9000
+db_url = "postgres://file/chirpy"
+polka_webhook_secrets = ["one", "two"]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(envFrom(map[string]string{
+				"CONFIG_FILE": writeFile(t, tt.file, tt.contents),
+				"PORT":        "9001",
+				"JWT_SECRET":  testJWTSecret,
+			}))
+			if err != nil {
+				t.Fatalf("load() error = %v", err)
+			}
+
+			if cfg.Port != 9001 {
+				t.Errorf("Port = %d, want the environment's 9001", cfg.Port)
+			}
+			if cfg.Platform != PlatformProduction || cfg.DatabaseURL != "postgres://file/chirpy" {
+				t.Errorf("Platform, DatabaseURL = %q, %q, want the config file's values", cfg.Platform, cfg.DatabaseURL)
+			}
+			if got := strings.Join(cfg.Polka.WebhookSecrets, ","); got != "one,two" {
+				t.Errorf("Polka.WebhookSecrets = %q, want one,two", got)
+			}
+		})
+	}
+}
+
+func TestLoadSecretFile(t *testing.T) {
+	env := map[string]string{
+		"PLATFORM":        PlatformDev,
+		"DB_URL":          "postgres://localhost/chirpy",
+		"JWT_SECRET_FILE": writeFile(t, "jwt_secret", testJWTSecret+"\n"),
+		"POLKA_KEY":       "polka",
+	}
+	cfg, err := load(envFrom(env))
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if cfg.JWTSecret != testJWTSecret {
+		t.Errorf("JWTSecret = %q, want the file's contents without the newline", cfg.JWTSecret)
+	}
+
+	env["JWT_SECRET"] = testJWTSecret
+	_, err = load(envFrom(env))
+	if err == nil || !strings.Contains(err.Error(), "only one of JWT_SECRET and JWT_SECRET_FILE") {
+		t.Errorf("load() error = %v, want a conflict between JWT_SECRET and JWT_SECRET_FILE", err)
+	}
+}
+
+func TestLoadSecretPrecedence(t *testing.T) {
+	const fileSecret = "fedcba9876543210fedcba9876543210"
+	secretFile := writeFile(t, "jwt_secret", fileSecret+"\n")
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		contents string
+		want     string
+	}{
+		{
+			name:     "Environment value beats file path in config file",
+			env:      map[string]string{"JWT_SECRET": testJWTSecret},
+			contents: "jwt_secret_file: " + secretFile + "\n",
+			want:     testJWTSecret,
+		},
+		{
+			name:     "Environment file path beats value in config file",
+			env:      map[string]string{"JWT_SECRET_FILE": secretFile},
+			contents: "jwt_secret: " + testJWTSecret + "\n",
+			want:     fileSecret,
+		},
+		{
+			name:     "Config file path",
+			contents: "jwt_secret_file: " + secretFile + "\n",
+			want:     fileSecret,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{
+				"PLATFORM":    PlatformDev,
+				"DB_URL":      "postgres://localhost/chirpy",
+				"POLKA_KEY":   "polka",
+				"CONFIG_FILE": writeFile(t, "chirpy.yaml", tt.contents),
+			}
+			for k, v := range tt.env {
+				env[k] = v
+			}
+			cfg, err := load(envFrom(env))
+			if err != nil {
+				t.Fatalf("load() error = %v", err)
+			}
+			if cfg.JWTSecret != tt.want {
+				t.Errorf("JWTSecret = %q, want %q", cfg.JWTSecret, tt.want)
+			}
+		})
+	}
+
+	_, err := load(envFrom(map[string]string{
+		"PLATFORM":  PlatformDev,
+		"DB_URL":    "postgres://localhost/chirpy",
+		"POLKA_KEY": "polka",
+		"CONFIG_FILE": writeFile(t, "chirpy.yaml",
+			"jwt_secret: "+testJWTSecret+"\njwt_secret_file: "+secretFile+"\n"),
+	}))
+	if err == nil || !strings.Contains(err.Error(), "only one of JWT_SECRET and JWT_SECRET_FILE") {
+		t.Errorf("load() error = %v, want a conflict within the config file", err)
+	}
+}
+
+func TestLoadReportsEveryError(t *testing.T) {
+	_, err := load(envFrom(map[string]string{
+		"PLATFORM":           "prod",
+		"JWT_SECRET":         "short",
+		"PORT":               "http",
+		"HTTP_READ_TIMEOUT":  "soon",
+		"ARGON2_ITERATIONS":  "0",
+		"ARGON2_MEMORY":      "16",
+		"ARGON2_PARALLELISM": "4",
+	}))
+	if err == nil {
+		t.Fatal("load() error = nil, want validation errors")
+	}
+
+	for _, key := range []string{"PLATFORM", "DB_URL", "JWT_SECRET", "POLKA_WEBHOOK_SECRETS", "PORT", "HTTP_READ_TIMEOUT", "ARGON2_ITERATIONS", "ARGON2_MEMORY"} {
+		if !strings.Contains(err.Error(), key+":") {
+			t.Errorf("error doesn't mention %s:\n%v", key, err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fileSuffix marks a variable naming a file that holds the real value, the
+// way Docker and Kubernetes mount secrets.
+const fileSuffix = "_FILE"
+
+// source looks keys up in the environment, then in the config file. Keys are
+// environment variable names; config files use the same names in lower case.
+type source struct {
+	env  func(string) (string, bool)
+	file map[string]string
+}
+
+func newSource(env func(string) (string, bool)) (*source, error) {
+	s := &source{env: env, file: map[string]string{}}
+
+	// CONFIG_FILE can't come from the config file itself.
+	path, ok := env("CONFIG_FILE")
+	if !ok || path == "" {
+		return s, nil
+	}
+	var err error
+	s.file, err = readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// lookup returns the value for key and whether any source set it.
+func (s *source) lookup(key string) (string, bool) {
+	if v, ok := s.env(key); ok {
+		return v, true
+	}
+	return s.fromFile(key)
+}
+
+func (s *source) fromFile(key string) (string, bool) {
+	v, ok := s.file[strings.ToLower(key)]
+	return v, ok
+}
+
+// lookupSecret returns the value of key, or the contents of the file named by
+// KEY_FILE. Like every other key, either one in the environment beats both in
+// the config file. Setting both in the same place is an error, since it's not
+// obvious which should win.
+func (s *source) lookupSecret(key string) (string, error) {
+	for _, get := range []func(string) (string, bool){s.env, s.fromFile} {
+		value, _ := get(key)
+		path, _ := get(key + fileSuffix)
+		switch {
+		case value != "" && path != "":
+			return "", fmt.Errorf("only one of %s and %s%s can be set", key, key, fileSuffix)
+		case value != "":
+			return value, nil
+		case path != "":
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimRight(string(data), "\r\n"), nil
+		}
+	}
+	return "", nil
+}
+
+// readConfigFile reads a flat YAML or TOML file into string values. Lists are
+// joined with commas to match how they're written in the environment.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read config file: %w", err)
+	}
+
+	raw := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file type %q: use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, v := range raw {
+		s, err := scalarString(v)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %s: %w", path, key, err)
+		}
+		values[strings.ToLower(key)] = s
+	}
+	return values, nil
+}
+
+func scalarString(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := scalarString(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, ","), nil
+	case map[string]any:
+		return "", errors.New("nested tables aren't supported")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,170 @@
+// Package contentfilter checks user-written text against a configurable word
+// list. Each word can be masked, cause the text to be rejected, or flag the
+// text for moderator review.
+package contentfilter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+)
+
+type Action string
+
+const (
+	ActionMask   Action = "mask"
+	ActionReject Action = "reject"
+	ActionFlag   Action = "flag"
+)
+
+const mask = "****"
+
+func ParseAction(s string) (Action, error) {
+	switch a := Action(strings.ToLower(strings.TrimSpace(s))); a {
+	case ActionMask, ActionReject, ActionFlag:
+		return a, nil
+	default:
+		return "", fmt.Errorf("unknown action %q: must be mask, reject or flag", s)
+	}
+}
+
+type Rule struct {
+	Word   string
+	Action Action
+}
+
+// DefaultRules is the word list Chirpy has always censored.
+var DefaultRules = []Rule{
+	{Word: "kerfuffle", Action: ActionMask},
+	{Word: "sharbert", Action: ActionMask},
+	{Word: "fornax", Action: ActionMask},
+}
+
+type Result struct {
+	// Text is the input with every masked word replaced.
+	Text string
+	// Rejected is set when any word's action is reject.
+	Rejected bool
+	// Flagged lists the matched words whose action is flag.
+	Flagged []string
+}
+
+// Filter is safe for concurrent use. Its rules can be swapped at any time,
+// which is how word lists are hot-reloaded.
+type Filter struct {
+	mu    sync.RWMutex
+	rules map[string]Action
+}
+
+func New(rules []Rule) *Filter {
+	f := &Filter{}
+	f.SetRules(rules)
+	return f
+}
+
+func (f *Filter) SetRules(rules []Rule) {
+	m := make(map[string]Action, len(rules))
+	for _, r := range rules {
+		if word := normalize(r.Word); word != "" {
+			m[word] = r.Action
+		}
+	}
+
+	f.mu.Lock()
+	f.rules = m
+	f.mu.Unlock()
+}
+
+// Check matches every word in text against the rules. Matching ignores case
+// and surrounding punctuation, so "Kerfuffle!" matches "kerfuffle"; masking
+// keeps the punctuation in place.
+func (f *Filter) Check(text string) Result {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	res := Result{}
+	var b strings.Builder
+	rest := text
+	for rest != "" {
+		start := strings.IndexFunc(rest, isWordRune)
+		if start < 0 {
+			b.WriteString(rest)
+			break
+		}
+		b.WriteString(rest[:start])
+		rest = rest[start:]
+
+		end := strings.IndexFunc(rest, func(r rune) bool { return !isWordRune(r) })
+		if end < 0 {
+			end = len(rest)
+		}
+		word := rest[:end]
+		rest = rest[end:]
+
+		action, found := f.rules[normalize(word)]
+		switch {
+		case !found:
+			b.WriteString(word)
+		case action == ActionMask:
+			b.WriteString(mask)
+		case action == ActionReject:
+			res.Rejected = true
+			b.WriteString(word)
+		case action == ActionFlag:
+			res.Flagged = append(res.Flagged, normalize(word))
+			b.WriteString(word)
+		}
+	}
+
+	res.Text = b.String()
+	return res
+}
+
+// Reload replaces the rules with the ones src currently holds. The old rules
+// stay in place if loading fails.
+func (f *Filter) Reload(ctx context.Context, src Source) error {
+	rules, err := src.Load(ctx)
+	if err != nil {
+		return err
+	}
+	f.SetRules(rules)
+	return nil
+}
+
+// Watch reloads the rules from src every interval until ctx is cancelled.
+func (f *Filter) Watch(ctx context.Context, src Source, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := f.Reload(ctx, src)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to reload content filter", "error", err)
+			}
+		}
+	}
+}
+
+// Length counts user-perceived characters, so an emoji built from several
+// code points counts once.
+func Length(text string) int {
+	return uniseg.GraphemeClusterCount(text)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+func normalize(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool { return !isWordRune(r) }))
+}
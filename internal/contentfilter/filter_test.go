@@ -0,0 +1,126 @@
+package contentfilter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	f := New([]Rule{
+		{Word: "kerfuffle", Action: ActionMask},
+		{Word: "Sharbert", Action: ActionMask},
+		{Word: "fornax", Action: ActionReject},
+		{Word: "spam", Action: ActionFlag},
+	})
+
+	tests := []struct {
+		name         string
+		input        string
+		wantText     string
+		wantRejected bool
+		wantFlagged  []string
+	}{
+		{
+			name:     "Clean text is unchanged",
+			input:    "I had a lovely day",
+			wantText: "I had a lovely day",
+		},
+		{
+			name:     "Masks regardless of case and punctuation",
+			input:    "What a Kerfuffle! Pass the sharbert, please",
+			wantText: "What a ****! Pass the ****, please",
+		},
+		{
+			name:     "Only whole words are masked",
+			input:    "kerfuffles aren't kerfuffle",
+			wantText: "kerfuffles aren't ****",
+		},
+		{
+			name:         "Reject words reject the text",
+			input:        "FORNAX.",
+			wantText:     "FORNAX.",
+			wantRejected: true,
+		},
+		{
+			name:        "Flag words are reported",
+			input:       "buy my spam",
+			wantText:    "buy my spam",
+			wantFlagged: []string{"spam"},
+		},
+		{
+			name:     "Non-ASCII text is preserved",
+			input:    "¡kerfuffle! 🎉 café",
+			wantText: "¡****! 🎉 café",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := f.Check(tt.input)
+			if res.Text != tt.wantText {
+				t.Errorf("Check().Text = %q, want %q", res.Text, tt.wantText)
+			}
+			if res.Rejected != tt.wantRejected {
+				t.Errorf("Check().Rejected = %v, want %v", res.Rejected, tt.wantRejected)
+			}
+			if !slices.Equal(res.Flagged, tt.wantFlagged) {
+				t.Errorf("Check().Flagged = %v, want %v", res.Flagged, tt.wantFlagged)
+			}
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{name: "ASCII", input: "hello", want: 5},
+		{name: "Accented", input: "café", want: 4},
+		{name: "Flag emoji", input: "🇳🇿", want: 1},
+		{name: "Family emoji", input: "👨‍👩‍👧", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Length(tt.input); got != tt.want {
+				t.Errorf("Length(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileSourceReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	err := os.WriteFile(path, []byte("# comment\nkerfuffle\nfornax reject\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := New(nil)
+	src := FileSource{Path: path}
+	if err := f.Reload(context.Background(), src); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := f.Check("kerfuffle").Text; got != mask {
+		t.Errorf("Check() after reload = %q, want %q", got, mask)
+	}
+	if !f.Check("fornax").Rejected {
+		t.Error("Check() after reload didn't reject fornax")
+	}
+
+	err = os.WriteFile(path, []byte("kerfuffle explode\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reload(context.Background(), src); err == nil {
+		t.Error("Reload() with a bad action succeeded")
+	}
+	if got := f.Check("kerfuffle").Text; got != mask {
+		t.Errorf("Check() after failed reload = %q, want the old rules to apply", got)
+	}
+}
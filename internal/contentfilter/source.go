@@ -0,0 +1,68 @@
+package contentfilter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Source supplies the current word list.
+type Source interface {
+	Load(ctx context.Context) ([]Rule, error)
+}
+
+// SourceFunc adapts a function, such as a database query, to a Source.
+type SourceFunc func(ctx context.Context) ([]Rule, error)
+
+func (fn SourceFunc) Load(ctx context.Context) ([]Rule, error) {
+	return fn(ctx)
+}
+
+// FileSource reads a word list with one word per line, optionally followed by
+// an action. Words without an action are masked. Blank lines and lines
+// starting with '#' are ignored:
+//
+//	kerfuffle
+//	sharbert mask
+//	fornax flag
+type FileSource struct {
+	Path string
+}
+
+func (s FileSource) Load(_ context.Context) ([]Rule, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open word list: %w", err)
+	}
+	defer f.Close()
+
+	var rules []Rule
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		rule := Rule{Word: fields[0], Action: ActionMask}
+		switch len(fields) {
+		case 1:
+		case 2:
+			rule.Action, err = ParseAction(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("word list line %d: %w", n, err)
+			}
+		default:
+			return nil, fmt.Errorf("word list line %d: expected a word and an optional action", n)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read word list: %w", err)
+	}
+
+	return rules, nil
+}
@@ -9,6 +9,7 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -20,7 +21,7 @@ VALUES (
     $1,
     $2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, role, protected, suspended_until, banned_at, moderation_reason, chirps_hidden
 `
 
 type CreateUserParams struct {
@@ -37,13 +38,68 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.Role,
+		&i.Protected,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.ModerationReason,
+		&i.ChirpsHidden,
 	)
 	return i, err
 }
 
+const getMentionableUsers = `-- name: GetMentionableUsers :many
+SELECT id, created_at, updated_at, email, hashed_password, role, protected, suspended_until, banned_at, moderation_reason, chirps_hidden FROM users
+WHERE email = ANY($1::TEXT[])
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = users.id AND blocks.blocked_id = $2
+)
+`
+
+type GetMentionableUsersParams struct {
+	Emails   []string
+	AuthorID uuid.UUID
+}
+
+// Users who have blocked the author can't be mentioned by them.
+func (q *Queries) GetMentionableUsers(ctx context.Context, arg GetMentionableUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getMentionableUsers, pq.Array(arg.Emails), arg.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.Role,
+			&i.Protected,
+			&i.SuspendedUntil,
+			&i.BannedAt,
+			&i.ModerationReason,
+			&i.ChirpsHidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, role, protected, suspended_until, banned_at, moderation_reason, chirps_hidden FROM users
 WHERE email = $1
 `
 
@@ -56,26 +112,54 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.Role,
+		&i.Protected,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.ModerationReason,
+		&i.ChirpsHidden,
 	)
 	return i, err
 }
 
-const updateUser = `-- name: UpdateUser :one
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, created_at, updated_at, email, hashed_password, role, protected, suspended_until, banned_at, moderation_reason, chirps_hidden FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.Role,
+		&i.Protected,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.ModerationReason,
+		&i.ChirpsHidden,
+	)
+	return i, err
+}
+
+const setUserProtected = `-- name: SetUserProtected :one
 UPDATE users
-SET email = $2, hashed_password = $3, updated_at = NOW()
+SET protected = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, role, protected, suspended_until, banned_at, moderation_reason, chirps_hidden
 `
 
-type UpdateUserParams struct {
-	ID             uuid.UUID
-	Email          string
-	HashedPassword string
+type SetUserProtectedParams struct {
+	ID        uuid.UUID
+	Protected bool
 }
 
-func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUser, arg.ID, arg.Email, arg.HashedPassword)
+func (q *Queries) SetUserProtected(ctx context.Context, arg SetUserProtectedParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserProtected, arg.ID, arg.Protected)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -83,20 +167,31 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.Role,
+		&i.Protected,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.ModerationReason,
+		&i.ChirpsHidden,
 	)
 	return i, err
 }
 
-const upgradeUser = `-- name: UpgradeUser :one
+const updateUser = `-- name: UpdateUser :one
 UPDATE users
-SET is_chirpy_red = true, updated_at = NOW()
+SET email = $2, hashed_password = $3, updated_at = NOW()
 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, role, protected, suspended_until, banned_at, moderation_reason, chirps_hidden
 `
 
-func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) (User, error) {
-	row := q.db.QueryRowContext(ctx, upgradeUser, id)
+type UpdateUserParams struct {
+	ID             uuid.UUID
+	Email          string
+	HashedPassword string
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUser, arg.ID, arg.Email, arg.HashedPassword)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -104,7 +199,28 @@ func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.UpdatedAt,
 		&i.Email,
 		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.Role,
+		&i.Protected,
+		&i.SuspendedUntil,
+		&i.BannedAt,
+		&i.ModerationReason,
+		&i.ChirpsHidden,
 	)
 	return i, err
 }
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET hashed_password = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID             uuid.UUID
+	HashedPassword string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.HashedPassword)
+	return err
+}
@@ -0,0 +1,70 @@
+package entitlements
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cache remembers each user's entitlements for a short while, for callers
+// such as the rate limiter that run on every request and can tolerate a
+// plan change taking TTL to show up.
+type Cache struct {
+	TTL  time.Duration
+	Load func(ctx context.Context, userID uuid.UUID) (Entitlements, error)
+
+	mu        sync.Mutex
+	entries   map[uuid.UUID]cacheEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type cacheEntry struct {
+	ent     Entitlements
+	expires time.Time
+}
+
+// Get returns the cached entitlements of userID, loading them if they're
+// missing or stale. Errors from Load aren't cached.
+func (c *Cache) Get(ctx context.Context, userID uuid.UUID) (Entitlements, error) {
+	now := c.clock()
+
+	c.mu.Lock()
+	if e, found := c.entries[userID]; found && now.Before(e.expires) {
+		c.mu.Unlock()
+		return e.ent, nil
+	}
+	c.mu.Unlock()
+
+	ent, err := c.Load(ctx, userID)
+	if err != nil {
+		return Entitlements{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[uuid.UUID]cacheEntry{}
+	}
+	// Drop expired entries now and then so users who stop making requests
+	// don't stay in memory forever.
+	if now.Sub(c.lastSweep) >= c.TTL {
+		for id, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, id)
+			}
+		}
+		c.lastSweep = now
+	}
+	c.entries[userID] = cacheEntry{ent: ent, expires: now.Add(c.TTL)}
+	return ent, nil
+}
+
+func (c *Cache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,58 @@
+package entitlements
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCacheReloadsAfterTTL(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := PlanFree
+	loads := 0
+	c := &Cache{
+		TTL: time.Minute,
+		Load: func(ctx context.Context, userID uuid.UUID) (Entitlements, error) {
+			loads++
+			return ForPlan(plan), nil
+		},
+		now: func() time.Time { return now },
+	}
+	userID := uuid.New()
+
+	c.Get(context.Background(), userID)
+	plan = PlanRed
+	now = now.Add(30 * time.Second)
+	if ent, _ := c.Get(context.Background(), userID); ent.Plan != PlanFree || loads != 1 {
+		t.Errorf("within TTL: got plan %q after %d loads, want %q after 1", ent.Plan, loads, PlanFree)
+	}
+
+	now = now.Add(time.Minute)
+	if ent, _ := c.Get(context.Background(), userID); ent.Plan != PlanRed || loads != 2 {
+		t.Errorf("after TTL: got plan %q after %d loads, want %q after 2", ent.Plan, loads, PlanRed)
+	}
+}
+
+func TestCacheDoesNotCacheErrors(t *testing.T) {
+	loads := 0
+	c := &Cache{
+		TTL: time.Minute,
+		Load: func(ctx context.Context, userID uuid.UUID) (Entitlements, error) {
+			loads++
+			return Entitlements{}, errors.New("database unavailable")
+		},
+	}
+	userID := uuid.New()
+
+	for range 2 {
+		if _, err := c.Get(context.Background(), userID); err == nil {
+			t.Fatal("Get() succeeded, want the load error")
+		}
+	}
+	if loads != 2 {
+		t.Errorf("Load called %d times, want 2", loads)
+	}
+}
@@ -0,0 +1,41 @@
+package entitlements
+
+// Plan identifies what a user is paying for.
+type Plan string
+
+const (
+	PlanFree Plan = "free"
+	PlanRed  Plan = "chirpy_red"
+)
+
+// Entitlements are the perks a plan unlocks.
+type Entitlements struct {
+	Plan           Plan
+	MaxChirpLength int
+	// RateLimitMultiplier scales the number of requests allowed per window.
+	RateLimitMultiplier int
+	CanEditChirps       bool
+}
+
+var plans = map[Plan]Entitlements{
+	PlanFree: {
+		Plan:                PlanFree,
+		MaxChirpLength:      140,
+		RateLimitMultiplier: 1,
+		CanEditChirps:       false,
+	},
+	PlanRed: {
+		Plan:                PlanRed,
+		MaxChirpLength:      500,
+		RateLimitMultiplier: 5,
+		CanEditChirps:       true,
+	},
+}
+
+// ForPlan returns the entitlements of a plan. Unknown plans get the free tier.
+func ForPlan(plan Plan) Entitlements {
+	if ent, found := plans[plan]; found {
+		return ent
+	}
+	return plans[PlanFree]
+}
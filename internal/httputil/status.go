@@ -0,0 +1,58 @@
+// Package httputil holds small net/http helpers shared by the server's
+// middleware.
+package httputil
+
+import "net/http"
+
+// StatusRecorder remembers the status code written through it. Unwrap lets
+// http.ResponseController reach the underlying writer, so streaming and
+// WebSocket handlers still work behind middleware that wraps the writer.
+type StatusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// NewStatusRecorder wraps w. If w already is a StatusRecorder, from an
+// outer middleware, it's returned as is so one recorder serves the whole
+// chain.
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	if rec, ok := w.(*StatusRecorder); ok {
+		return rec
+	}
+	return &StatusRecorder{ResponseWriter: w}
+}
+
+func (r *StatusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *StatusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush lets handlers that type-assert http.Flusher, rather than going
+// through http.ResponseController, stream through the recorder.
+func (r *StatusRecorder) Flush() {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+func (r *StatusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Status returns the status code sent, or 200 if the handler never wrote one.
+func (r *StatusRecorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
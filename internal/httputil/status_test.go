@@ -0,0 +1,49 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusRecorderDefaultsToOK(t *testing.T) {
+	rec := NewStatusRecorder(httptest.NewRecorder())
+	rec.Write([]byte("hello"))
+	rec.WriteHeader(http.StatusTeapot)
+	if got := rec.Status(); got != http.StatusOK {
+		t.Errorf("Status() = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestNewStatusRecorderReusesRecorder(t *testing.T) {
+	outer := NewStatusRecorder(httptest.NewRecorder())
+	inner := NewStatusRecorder(outer)
+	if inner != outer {
+		t.Fatal("NewStatusRecorder wrapped a StatusRecorder again")
+	}
+	inner.WriteHeader(http.StatusNotFound)
+	if got := outer.Status(); got != http.StatusNotFound {
+		t.Errorf("Status() = %d, want %d", got, http.StatusNotFound)
+	}
+}
+
+func TestStatusRecorderPassesThrough(t *testing.T) {
+	w := httptest.NewRecorder()
+	rec := NewStatusRecorder(w)
+
+	if got := rec.Unwrap(); got != w {
+		t.Errorf("Unwrap() = %v, want the wrapped writer", got)
+	}
+
+	var _ http.Flusher = rec
+	err := http.NewResponseController(rec).Flush()
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if !w.Flushed {
+		t.Error("Flush() didn't reach the wrapped writer")
+	}
+	if got := rec.Status(); got != http.StatusOK {
+		t.Errorf("Status() = %d, want %d", got, http.StatusOK)
+	}
+}
@@ -0,0 +1,120 @@
+// Package logging sets up structured JSON logging and carries a request-scoped
+// logger, tagged with the request's ID, through context.Context.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/httputil"
+)
+
+// RequestIDHeader carries the request ID. An ID sent by the client or a proxy
+// is kept so logs can be correlated across services; otherwise one is made up.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds IDs accepted from clients.
+const maxRequestIDLength = 128
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	requestIDKey
+)
+
+// New returns a JSON logger writing to w at the given level.
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// ParseLevel parses debug, info, warn or error. An empty string means info.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	err := level.UnmarshalText([]byte(s))
+	return level, err
+}
+
+// WithLogger returns a copy of ctx carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// FromContext returns the logger carried by ctx, or slog.Default() if there
+// isn't one.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RequestID returns the ID of the request ctx belongs to, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// Middleware assigns every request an ID, echoes it in the response, and puts
+// a logger tagged with it in the request context. Once the request is done it
+// writes an access log entry with the matched mux pattern, status, duration
+// and, when userID reports one, the caller's user ID.
+func Middleware(logger *slog.Logger, mux *http.ServeMux, userID func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		reqLogger := logger.With("request_id", id)
+		ctx := context.WithValue(req.Context(), requestIDKey, id)
+		ctx = WithLogger(ctx, reqLogger)
+		req = req.WithContext(ctx)
+
+		_, route := mux.Handler(req)
+		rec := httputil.NewStatusRecorder(w)
+		start := time.Now()
+		next.ServeHTTP(rec, req)
+
+		attrs := []any{
+			"method", req.Method,
+			"path", req.URL.Path,
+			"route", route,
+			"status", rec.Status(),
+			"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if uid := userID(req); uid != "" {
+			attrs = append(attrs, "user_id", uid)
+		}
+		reqLogger.InfoContext(ctx, "request completed", attrs...)
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID accepts short IDs made of printable ASCII, so a client can't
+// inject arbitrary data into the logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
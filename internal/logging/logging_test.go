@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		wantKept  bool
+	}{
+		{name: "propagates a valid ID", requestID: "abc-123", wantKept: true},
+		{name: "replaces a missing ID", requestID: "", wantKept: false},
+		{name: "replaces an ID with spaces", requestID: "abc 123", wantKept: false},
+		{name: "replaces an overlong ID", requestID: strings.Repeat("a", maxRequestIDLength+1), wantKept: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var ctxID string
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/chirps/{chirpID}", func(w http.ResponseWriter, r *http.Request) {
+				ctxID = RequestID(r.Context())
+				FromContext(r.Context()).Info("handled")
+				w.WriteHeader(http.StatusTeapot)
+			})
+			handler := Middleware(New(&buf, 0), mux, func(*http.Request) string { return "user-1" }, mux)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/chirps/42", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(RequestIDHeader)
+			if got == "" || got != ctxID {
+				t.Fatalf("response ID %q doesn't match context ID %q", got, ctxID)
+			}
+			if kept := got == tt.requestID; kept != tt.wantKept {
+				t.Errorf("kept client ID = %v, want %v", kept, tt.wantKept)
+			}
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("got %d log lines, want 2", len(lines))
+			}
+			access := map[string]any{}
+			if err := json.Unmarshal([]byte(lines[1]), &access); err != nil {
+				t.Fatal(err)
+			}
+			want := map[string]any{
+				"request_id": got,
+				"route":      "GET /api/chirps/{chirpID}",
+				"status":     float64(http.StatusTeapot),
+				"user_id":    "user-1",
+			}
+			for k, v := range want {
+				if access[k] != v {
+					t.Errorf("access log %s = %v, want %v", k, access[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Package metrics exposes the server's Prometheus metrics: per-route request
+// counts and latencies, connection pool stats, Go runtime stats and a handful
+// of application counters.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/httputil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "chirpy"
+
+// unmatchedRoute labels requests that didn't match any pattern, so stray
+// paths can't blow up the number of series.
+const unmatchedRoute = "unmatched"
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests      *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	logins        *prometheus.CounterVec
+	chirpsCreated prometheus.Counter
+	webhooks      *prometheus.CounterVec
+
+	notificationsDropped prometheus.Counter
+}
+
+// New registers the server's metrics, along with Go runtime, process and
+// connection pool collectors, on a fresh registry. db may be nil.
+func New(db *sql.DB) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route pattern and status code.",
+		}, []string{"method", "route", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route pattern.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts by result.",
+		}, []string{"result"}),
+		chirpsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "chirps_created_total",
+			Help:      "Chirps created.",
+		}),
+		webhooks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhooks_total",
+			Help:      "Webhook outcomes: incoming deliveries by provider and outgoing attempts by result.",
+		}, []string{"direction", "provider", "outcome"}),
+		notificationsDropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "notifications_dropped_total",
+			Help:      "Notifications dropped because the queue was full or shutdown cut the drain short.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.duration,
+		m.logins,
+		m.chirpsCreated,
+		m.webhooks,
+		m.notificationsDropped,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	if db != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+	}
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Middleware wraps next, recording every request under the mux pattern it
+// matches. It should be the outermost handler so that responses written by
+// other middleware, such as rate limiting, are counted too.
+func (m *Metrics) Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, route := mux.Handler(req)
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		rec := httputil.NewStatusRecorder(w)
+		start := time.Now()
+		next.ServeHTTP(rec, req)
+
+		m.duration.WithLabelValues(req.Method, route).Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(req.Method, route, strconv.Itoa(rec.Status())).Inc()
+	})
+}
+
+// ObserveLogin counts a login attempt. result is "success" or the reason it
+// failed.
+func (m *Metrics) ObserveLogin(result string) {
+	m.logins.WithLabelValues(result).Inc()
+}
+
+func (m *Metrics) ObserveChirpCreated() {
+	m.chirpsCreated.Inc()
+}
+
+// ObserveWebhookReceived counts an incoming webhook delivery from provider by
+// how it was handled.
+func (m *Metrics) ObserveWebhookReceived(provider, outcome string) {
+	m.webhooks.WithLabelValues("incoming", provider, outcome).Inc()
+}
+
+// ObserveWebhookDelivered counts an attempt to deliver an outgoing webhook.
+func (m *Metrics) ObserveWebhookDelivered(outcome string) {
+	m.webhooks.WithLabelValues("outgoing", "", outcome).Inc()
+}
+
+// ObserveNotificationsDropped counts notification jobs that were never
+// delivered.
+func (m *Metrics) ObserveNotificationsDropped(n int) {
+	m.notificationsDropped.Add(float64(n))
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+)
+
+// Buckets untouched for this long are assumed to be full and get deleted.
+const staleBucketAge = 24 * time.Hour
+
+// PostgresStore keeps buckets in the rate_limit_buckets table so that every
+// replica draws from the same buckets.
+type PostgresStore struct {
+	db *database.Queries
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresStore(db *database.Queries) *PostgresStore {
+	return &PostgresStore{
+		db:        db,
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.sweep(ctx)
+
+	row, err := s.db.TakeRateLimitToken(ctx, database.TakeRateLimitTokenParams{
+		Key:      key,
+		Capacity: float64(limit.Requests),
+		Rate:     limit.rate(),
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	return newResult(limit, row.Tokens, row.Allowed), nil
+}
+
+func (s *PostgresStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	s.mu.Unlock()
+
+	err := s.db.DeleteStaleRateLimitBuckets(ctx, time.Now().UTC().Add(-staleBucketAge))
+	if err != nil {
+		slog.Error("failed to delete stale rate limit buckets", "error", err)
+	}
+}
@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit describes a token bucket that holds up to Requests tokens and refills
+// completely once every Per.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l Limit) IsZero() bool {
+	return l.Requests <= 0 || l.Per <= 0
+}
+
+// rate is the refill rate in tokens per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next request would be allowed
+}
+
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	res := Result{
+		Allowed:    allowed,
+		Limit:      limit.Requests,
+		Remaining:  max(int(math.Floor(tokens)), 0),
+		ResetAfter: secondsToDuration((float64(limit.Requests) - tokens) / limit.rate()),
+	}
+	if !allowed {
+		res.RetryAfter = secondsToDuration((1 - tokens) / limit.rate())
+	}
+	return res
+}
+
+func secondsToDuration(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
+
+// Store is a rate limit backend. Take removes one token from the bucket
+// identified by key, reporting whether the request is allowed.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// ParseLimit parses a limit written as "<requests>/<duration>", e.g. "10/1m".
+func ParseLimit(s string) (Limit, error) {
+	requests, per, found := strings.Cut(strings.TrimSpace(s), "/")
+	if !found {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected <requests>/<duration>", s)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("invalid request count in rate limit %q", s)
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid duration in rate limit %q", s)
+	}
+
+	return Limit{Requests: n, Per: d}, nil
+}
+
+// ParseRouteLimits parses a comma-separated list of "<route pattern>=<limit>"
+// pairs, e.g. "POST /api/chirps=10/1m,POST /api/login=5/1m".
+func ParseRouteLimits(s string) (map[string]Limit, error) {
+	limits := map[string]Limit{}
+	if strings.TrimSpace(s) == "" {
+		return limits, nil
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		route, limitString, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid route limit %q: expected <route>=<limit>", entry)
+		}
+		limit, err := ParseLimit(limitString)
+		if err != nil {
+			return nil, err
+		}
+		limits[strings.TrimSpace(route)] = limit
+	}
+
+	return limits, nil
+}
+
+// Limiter picks a limit for each route and draws tokens from its Store.
+type Limiter struct {
+	Store        Store
+	DefaultLimit Limit
+	RouteLimits  map[string]Limit
+}
+
+// LimitFor returns the limit configured for a route pattern, falling back to
+// the default limit. A zero Limit means the route isn't rate limited.
+func (l *Limiter) LimitFor(route string) Limit {
+	if limit, found := l.RouteLimits[route]; found {
+		return limit
+	}
+	return l.DefaultLimit
+}
+
+// Take draws a token from the bucket shared by route and identity, so a client
+// exhausting one route can still use the others.
+func (l *Limiter) Take(ctx context.Context, route, identity string, limit Limit) (Result, error) {
+	return l.Store.Take(ctx, route+"|"+identity, limit)
+}
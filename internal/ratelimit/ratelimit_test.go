@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Requests: 2, Per: time.Minute}
+
+	tests := []struct {
+		name          string
+		advance       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{
+			name:          "First request",
+			advance:       0,
+			wantAllowed:   true,
+			wantRemaining: 1,
+		},
+		{
+			name:          "Second request uses the burst",
+			advance:       0,
+			wantAllowed:   true,
+			wantRemaining: 0,
+		},
+		{
+			name:          "Third request is limited",
+			advance:       0,
+			wantAllowed:   false,
+			wantRemaining: 0,
+		},
+		{
+			name:          "Bucket refills over time",
+			advance:       30 * time.Second,
+			wantAllowed:   true,
+			wantRemaining: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			res, err := store.Take(context.Background(), "key", limit)
+			if err != nil {
+				t.Fatalf("Take() error = %v", err)
+			}
+			if res.Allowed != tt.wantAllowed {
+				t.Errorf("Take() allowed = %v, want %v", res.Allowed, tt.wantAllowed)
+			}
+			if res.Remaining != tt.wantRemaining {
+				t.Errorf("Take() remaining = %v, want %v", res.Remaining, tt.wantRemaining)
+			}
+			if !res.Allowed && res.RetryAfter <= 0 {
+				t.Errorf("Take() retry after = %v, want > 0", res.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestParseRouteLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]Limit
+		wantErr bool
+	}{
+		{
+			name:  "Empty",
+			input: "",
+			want:  map[string]Limit{},
+		},
+		{
+			name:  "Several routes",
+			input: "POST /api/chirps=10/1m, POST /api/login=5/30s",
+			want: map[string]Limit{
+				"POST /api/chirps": {Requests: 10, Per: time.Minute},
+				"POST /api/login":  {Requests: 5, Per: 30 * time.Second},
+			},
+		},
+		{
+			name:    "Missing limit",
+			input:   "POST /api/chirps",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid duration",
+			input:   "POST /api/chirps=10/often",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRouteLimits(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRouteLimits() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseRouteLimits() = %v, want %v", got, tt.want)
+			}
+			for route, limit := range tt.want {
+				if got[route] != limit {
+					t.Errorf("ParseRouteLimits()[%q] = %v, want %v", route, got[route], limit)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventChirpCreated  = "chirp.created"
+	EventChirpUpdated  = "chirp.updated"
+	EventChirpDeleted  = "chirp.deleted"
+	EventChirpRestored = "chirp.restored"
+	EventChirpLiked    = "chirp.liked"
+
+	// TopicFeed carries every public chirp event.
+	TopicFeed = "feed"
+
+	// Recent events are kept so reconnecting clients can resume with Last-Event-ID.
+	defaultReplayBufferSize = 1024
+	subscriberBufferSize    = 64
+)
+
+type Event struct {
+	ID       uint64          `json:"id"`
+	Type     string          `json:"type"`
+	AuthorID uuid.UUID       `json:"author_id"`
+	Topics   []string        `json:"topics"`
+	Data     json.RawMessage `json:"data"`
+}
+
+func (e Event) HasTopic(topic string) bool {
+	return slices.Contains(e.Topics, topic)
+}
+
+// UserTopic carries events about a user's chirps.
+func UserTopic(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// ThreadTopic carries events about the chirps in a thread.
+func ThreadTopic(chirpID uuid.UUID) string {
+	return "thread:" + chirpID.String()
+}
+
+// NotificationsTopic is private to the user it's addressed to.
+func NotificationsTopic(userID uuid.UUID) string {
+	return "notifications:" + userID.String()
+}
+
+// Publisher sends events to every instance's Hub.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// Hub fans events out to the subscribers connected to this instance. Publishing
+// never blocks: a subscriber that falls behind is disconnected and can resume
+// from its last event ID.
+type Hub struct {
+	mu     sync.Mutex
+	nextID uint64
+	replay []Event
+	head   int
+	subs   map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		replay: make([]Event, 0, defaultReplayBufferSize),
+		subs:   map[*Subscription]struct{}{},
+	}
+}
+
+type Subscription struct {
+	events chan Event
+	filter func(Event) bool
+	hub    *Hub
+	once   sync.Once
+}
+
+// Events is closed when the subscription ends, either through Close or
+// because the subscriber couldn't keep up.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// Publish assigns the next local ID to events that don't have one yet, which
+// is how events published in-process are numbered.
+func (h *Hub) Publish(_ context.Context, e Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if e.ID == 0 {
+		e.ID = h.nextID + 1
+	}
+	h.nextID = max(h.nextID, e.ID)
+	h.remember(e)
+
+	for sub := range h.subs {
+		if sub.filter != nil && !sub.filter(e) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			h.remove(sub)
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a subscriber and returns the buffered events after
+// lastEventID that match filter. A nil filter matches everything.
+func (h *Hub) Subscribe(lastEventID uint64, filter func(Event) bool) (*Subscription, []Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &Subscription{
+		events: make(chan Event, subscriberBufferSize),
+		filter: filter,
+		hub:    h,
+	}
+	h.subs[sub] = struct{}{}
+
+	var backlog []Event
+	if lastEventID > 0 {
+		for i := range len(h.replay) {
+			e := h.replay[(h.head+i)%len(h.replay)]
+			if e.ID > lastEventID && (filter == nil || filter(e)) {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	return sub, backlog
+}
+
+func (h *Hub) remember(e Event) {
+	if len(h.replay) < cap(h.replay) {
+		h.replay = append(h.replay, e)
+		return
+	}
+	h.replay[h.head] = e
+	h.head = (h.head + 1) % len(h.replay)
+}
+
+// remove must be called with h.mu held.
+func (h *Hub) remove(sub *Subscription) {
+	if _, found := h.subs[sub]; !found {
+		return
+	}
+	delete(h.subs, sub)
+	sub.once.Do(func() { close(sub.events) })
+}
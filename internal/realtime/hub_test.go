@@ -0,0 +1,74 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHubSubscribeReplaysMissedEvents(t *testing.T) {
+	hub := NewHub()
+	author := uuid.New()
+	other := uuid.New()
+	for _, authorID := range []uuid.UUID{author, other, author, other} {
+		hub.Publish(context.Background(), Event{Type: EventChirpCreated, AuthorID: authorID})
+	}
+
+	tests := []struct {
+		name        string
+		lastEventID uint64
+		filter      func(Event) bool
+		wantIDs     []uint64
+	}{
+		{
+			name:        "New subscriber gets no backlog",
+			lastEventID: 0,
+			wantIDs:     nil,
+		},
+		{
+			name:        "Resume after an event",
+			lastEventID: 2,
+			wantIDs:     []uint64{3, 4},
+		},
+		{
+			name:        "Resume with a filter",
+			lastEventID: 1,
+			filter:      func(e Event) bool { return e.AuthorID == author },
+			wantIDs:     []uint64{3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, backlog := hub.Subscribe(tt.lastEventID, tt.filter)
+			defer sub.Close()
+
+			if len(backlog) != len(tt.wantIDs) {
+				t.Fatalf("Subscribe() backlog has %d events, want %d", len(backlog), len(tt.wantIDs))
+			}
+			for i, e := range backlog {
+				if e.ID != tt.wantIDs[i] {
+					t.Errorf("backlog[%d].ID = %d, want %d", i, e.ID, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+	sub, _ := hub.Subscribe(0, nil)
+
+	for range subscriberBufferSize + 1 {
+		hub.Publish(context.Background(), Event{Type: EventChirpCreated})
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	if received != subscriberBufferSize {
+		t.Errorf("slow subscriber received %d events before being dropped, want %d", received, subscriberBufferSize)
+	}
+}
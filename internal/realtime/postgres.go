@@ -0,0 +1,75 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/lib/pq"
+)
+
+// notifyChannel must match the channel name used by the NotifyRealtimeEvent query.
+const notifyChannel = "chirpy_realtime"
+
+// PostgresPublisher numbers events from a shared sequence and broadcasts them
+// with NOTIFY, so every instance listening with ListenPostgres receives them
+// under the same ID.
+type PostgresPublisher struct {
+	db *database.Queries
+}
+
+func NewPostgresPublisher(db *database.Queries) *PostgresPublisher {
+	return &PostgresPublisher{db: db}
+}
+
+func (p *PostgresPublisher) Publish(ctx context.Context, e Event) error {
+	id, err := p.db.NextRealtimeEventID(ctx)
+	if err != nil {
+		return err
+	}
+	e.ID = uint64(id)
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return p.db.NotifyRealtimeEvent(ctx, string(payload))
+}
+
+// ListenPostgres feeds events published by any instance into hub until ctx is
+// cancelled.
+func ListenPostgres(ctx context.Context, dbURL string, hub *Hub) error {
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.Error("realtime listener error", "error", err)
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(notifyChannel)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			// A nil notification means the connection was re-established and
+			// anything sent in between was lost.
+			if n == nil {
+				continue
+			}
+			e := Event{}
+			err := json.Unmarshal([]byte(n.Extra), &e)
+			if err != nil {
+				slog.Error("realtime listener couldn't decode event", "error", err)
+				continue
+			}
+			hub.Publish(ctx, e)
+		}
+	}
+}
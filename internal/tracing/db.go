@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const sqlcNamePrefix = "-- name: "
+
+// WrapDBTX returns a database.DBTX that records a client span around every
+// query, named after the sqlc query it runs. Spans for QueryContext end when
+// the query returns, before its rows are read.
+func WrapDBTX(db database.DBTX) database.DBTX {
+	return tracedDB{db: db, tracer: otel.Tracer(instrumentationName)}
+}
+
+type tracedDB struct {
+	db     database.DBTX
+	tracer trace.Tracer
+}
+
+func (t tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	res, err := t.db.ExecContext(ctx, query, args...)
+	recordError(span, err)
+	return res, err
+}
+
+func (t tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	stmt, err := t.db.PrepareContext(ctx, query)
+	recordError(span, err)
+	return stmt, err
+}
+
+func (t tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	recordError(span, err)
+	return rows, err
+}
+
+func (t tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	row := t.db.QueryRowContext(ctx, query, args...)
+	recordError(span, row.Err())
+	return row
+}
+
+func (t tracedDB) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := QueryName(query)
+	return t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", name),
+		),
+	)
+}
+
+// QueryName returns the sqlc query name from the "-- name: GetChirp :one"
+// comment sqlc puts at the top of every query, or "db.query" if there isn't
+// one.
+func QueryName(query string) string {
+	rest, ok := strings.CutPrefix(query, sqlcNamePrefix)
+	if !ok {
+		return "db.query"
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
+
+func recordError(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,20 @@
+package tracing
+
+import "testing"
+
+func TestQueryName(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "-- name: GetChirp :one\nSELECT * FROM chirps WHERE id = $1", want: "GetChirp"},
+		{query: "-- name: Reset :exec\nDELETE FROM users", want: "Reset"},
+		{query: "SELECT 1", want: "db.query"},
+	}
+
+	for _, tt := range tests {
+		if got := QueryName(tt.query); got != tt.want {
+			t.Errorf("QueryName(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
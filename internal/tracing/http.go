@@ -0,0 +1,21 @@
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Middleware starts a server span for every request, named after the mux
+// pattern it matches, and continues any trace propagated by the caller.
+func Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+			_, pattern := mux.Handler(req)
+			if pattern == "" {
+				return "unmatched"
+			}
+			return pattern
+		}),
+	)
+}
@@ -0,0 +1,77 @@
+// Package tracing sets up OpenTelemetry tracing and instruments the HTTP and
+// database layers. With tracing turned off, the global no-op provider is left
+// in place and instrumentation costs next to nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	ExporterNone = "none"
+	// ExporterOTLP exports over OTLP/HTTP. The collector endpoint, headers and
+	// TLS settings come from the standard OTEL_EXPORTER_OTLP_* variables, so
+	// pointing at a local collector only takes
+	// OTEL_EXPORTER_OTLP_ENDPOINT=http://localhost:4318.
+	ExporterOTLP = "otlp"
+)
+
+const instrumentationName = "github.com/CybrRonin/Chirpy/internal/tracing"
+
+type Config struct {
+	// Exporter is ExporterNone (or empty) to turn tracing off, or ExporterOTLP.
+	Exporter    string
+	ServiceName string
+	// SampleRatio is the fraction of new traces that are recorded. Traces
+	// started upstream follow the caller's sampling decision.
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and propagator described by cfg.
+// The returned function flushes buffered spans and should be called before
+// the process exits.
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+	default:
+		return nil, fmt.Errorf("unknown trace exporter: %s", cfg.Exporter)
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create OTLP exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", cfg.ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
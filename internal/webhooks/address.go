@@ -0,0 +1,69 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// ErrPrivateAddress is returned for subscriber hosts that resolve to
+// loopback, private, link-local or otherwise non-public addresses. Letting
+// subscribers point there would let anyone who can register one make the
+// server send requests into its own network.
+var ErrPrivateAddress = errors.New("address is not publicly routable")
+
+// nonPublicPrefixes are ranges IsGlobalUnicast and IsPrivate let through that
+// still don't belong to the public internet.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	// Carrier-grade NAT; some clouds serve metadata from here.
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// PublicAddr reports whether ip is a public unicast address.
+func PublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost resolves host and returns ErrPrivateAddress if any of its
+// addresses isn't public. The worker checks again when it connects, since
+// the host may resolve differently by then.
+func CheckHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !PublicAddr(addr) {
+			return fmt.Errorf("%s: %w", host, ErrPrivateAddress)
+		}
+	}
+	return nil
+}
+
+// dialControl refuses connections to non-public addresses. It runs on the
+// address actually being dialled, after DNS resolution, so a subscriber
+// can't get past CheckHost by changing its DNS records.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !PublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%s: %w", addrPort.Addr(), ErrPrivateAddress)
+	}
+	return nil
+}
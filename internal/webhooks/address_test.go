@@ -0,0 +1,56 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1::1", want: true},
+		{addr: "127.0.0.1", want: false},
+		{addr: "::1", want: false},
+		{addr: "10.1.2.3", want: false},
+		{addr: "172.16.0.1", want: false},
+		{addr: "192.168.1.1", want: false},
+		{addr: "169.254.169.254", want: false},
+		{addr: "100.100.100.200", want: false},
+		{addr: "0.0.0.0", want: false},
+		{addr: "fd00::1", want: false},
+		{addr: "fe80::1", want: false},
+		{addr: "::ffff:127.0.0.1", want: false},
+		{addr: "224.0.0.1", want: false},
+	}
+
+	for _, tt := range tests {
+		got := PublicAddr(netip.MustParseAddr(tt.addr))
+		if got != tt.want {
+			t.Errorf("PublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		wantErr error
+	}{
+		{host: "93.184.216.34", wantErr: nil},
+		{host: "127.0.0.1", wantErr: ErrPrivateAddress},
+		{host: "169.254.169.254", wantErr: ErrPrivateAddress},
+		{host: "::1", wantErr: ErrPrivateAddress},
+	}
+
+	for _, tt := range tests {
+		err := CheckHost(context.Background(), tt.host)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("CheckHost(%s) error = %v, want %v", tt.host, err, tt.wantErr)
+		}
+	}
+}
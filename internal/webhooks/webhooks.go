@@ -0,0 +1,64 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	EventChirpCreated  = "chirp.created"
+	EventChirpUpdated  = "chirp.updated"
+	EventChirpDeleted  = "chirp.deleted"
+	EventChirpRestored = "chirp.restored"
+	EventUserUpgraded  = "user.upgraded"
+
+	SignatureHeader = "X-Chirpy-Signature"
+	EventIDHeader   = "X-Chirpy-Event-ID"
+)
+
+var EventTypes = []string{
+	EventChirpCreated,
+	EventChirpUpdated,
+	EventChirpDeleted,
+	EventChirpRestored,
+	EventUserUpgraded,
+}
+
+// Envelope is the body of every delivery.
+type Envelope struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Enqueue writes an event to the outbox. q should be bound to the same
+// transaction as the change the event describes, so the event is recorded if
+// and only if that change commits.
+func Enqueue(ctx context.Context, q database.Querier, eventType string, data any) error {
+	dat, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	envelope := Envelope{
+		ID:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      dat,
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	return q.CreateWebhookOutboxEvent(ctx, database.CreateWebhookOutboxEventParams{
+		ID:        envelope.ID,
+		EventType: eventType,
+		Payload:   payload,
+	})
+}
@@ -0,0 +1,219 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/auth"
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/tracing"
+	"github.com/google/uuid"
+)
+
+const (
+	deliveryStatusPending = "pending"
+	deliveryStatusFailed  = "failed"
+)
+
+// Outcomes of a delivery attempt, as reported to Worker.OnAttempt.
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeRetrying  = "retrying"
+	OutcomeFailed    = "failed"
+)
+
+// Worker moves events from the outbox into per-subscriber deliveries and
+// sends due deliveries, retrying failures with exponential backoff. Several
+// workers can run against the same database; rows are claimed with
+// SKIP LOCKED so each delivery is attempted by one worker at a time.
+type Worker struct {
+	db      *sql.DB
+	queries *database.Queries
+	client  *http.Client
+
+	BatchSize   int32
+	MaxAttempts int32
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Lease is the margin a claimed batch stays hidden from other workers on
+	// top of the time it takes to send every delivery in it; see claimLease.
+	Lease time.Duration
+	// OnAttempt, if set, is called with the outcome of every delivery attempt.
+	OnAttempt func(outcome string)
+	// AllowPrivateAddresses lets deliveries go to loopback and private
+	// addresses, for receivers running alongside a dev server.
+	AllowPrivateAddresses bool
+}
+
+func NewWorker(db *sql.DB, queries *database.Queries) *Worker {
+	w := &Worker{
+		db:          db,
+		queries:     queries,
+		BatchSize:   50,
+		MaxAttempts: 8,
+		BaseBackoff: 30 * time.Second,
+		MaxBackoff:  6 * time.Hour,
+		Lease:       time.Minute,
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			if w.AllowPrivateAddresses {
+				return nil
+			}
+			return dialControl(network, address, c)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would make the dialer see the proxy's address instead of the
+	// subscriber's.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	w.client = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	return w
+}
+
+// Run polls until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.dispatch(ctx); err != nil {
+			slog.ErrorContext(ctx, "failed to dispatch webhook outbox", "error", err)
+		}
+		if err := w.deliverDue(ctx); err != nil {
+			slog.ErrorContext(ctx, "failed to deliver webhooks", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch fans each new outbox event out to the subscribers listening for it.
+func (w *Worker) dispatch(ctx context.Context) error {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := database.New(tracing.WrapDBTX(tx))
+
+	events, err := qtx.ClaimUndispatchedOutboxEvents(ctx, w.BatchSize)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		_, err := qtx.CreateWebhookDeliveriesForEvent(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+		err = qtx.MarkOutboxEventDispatched(ctx, event.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (w *Worker) deliverDue(ctx context.Context) error {
+	deliveries, err := w.queries.ClaimDueWebhookDeliveries(ctx, database.ClaimDueWebhookDeliveriesParams{
+		LeaseUntil: time.Now().UTC().Add(w.claimLease()),
+		BatchSize:  w.BatchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, d := range deliveries {
+		statusCode, sendErr := w.send(ctx, d.Url, d.Secret, d.ID, d.Payload)
+		responseStatus := sql.NullInt32{Int32: int32(statusCode), Valid: statusCode != 0}
+		outcome := OutcomeSucceeded
+		if sendErr == nil {
+			err = w.queries.MarkWebhookDeliverySucceeded(ctx, database.MarkWebhookDeliverySucceededParams{
+				ID:                 d.ID,
+				LastResponseStatus: responseStatus,
+			})
+		} else {
+			attempts := d.Attempts + 1
+			status := deliveryStatusPending
+			outcome = OutcomeRetrying
+			if attempts >= w.MaxAttempts {
+				status = deliveryStatusFailed
+				outcome = OutcomeFailed
+			}
+			err = w.queries.MarkWebhookDeliveryFailed(ctx, database.MarkWebhookDeliveryFailedParams{
+				ID:                 d.ID,
+				Status:             status,
+				NextAttemptAt:      time.Now().UTC().Add(Backoff(attempts, w.BaseBackoff, w.MaxBackoff)),
+				LastResponseStatus: responseStatus,
+				LastError:          sql.NullString{String: sendErr.Error(), Valid: true},
+			})
+		}
+		if err != nil {
+			return err
+		}
+		if w.OnAttempt != nil {
+			w.OnAttempt(outcome)
+		}
+	}
+
+	return nil
+}
+
+// claimLease is how long a claimed batch stays hidden from other workers.
+// Deliveries are sent one after another, so the last one in a batch may not
+// be attempted until every earlier one has timed out.
+func (w *Worker) claimLease() time.Duration {
+	return time.Duration(w.BatchSize)*w.client.Timeout + w.Lease
+}
+
+// send POSTs a signed payload. Any 2XX response counts as delivered.
+func (w *Worker) send(ctx context.Context, url, secret string, deliveryID uuid.UUID, payload []byte) (statusCode int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, deliveryID.String())
+	req.Header.Set(SignatureHeader, auth.SignWebhookPayload(payload, secret, time.Now()))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff returns the delay before the next attempt once attempts have failed:
+// base, 2*base, 4*base, ... capped at maxDelay.
+func Backoff(attempts int32, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	return min(delay, maxDelay)
+}
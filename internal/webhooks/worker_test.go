@@ -0,0 +1,101 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/auth"
+	"github.com/google/uuid"
+)
+
+func TestWorkerSend(t *testing.T) {
+	const secret = "subscriber-secret"
+	payload := []byte(`{"id":"6f1f0b7e-8a8e-4f61-9a49-0d4a1c1f4b7e","type":"chirp.created","data":{}}`)
+
+	tests := []struct {
+		name           string
+		responseStatus int
+		wantErr        bool
+	}{
+		{
+			name:           "Receiver accepts delivery",
+			responseStatus: http.StatusNoContent,
+			wantErr:        false,
+		},
+		{
+			name:           "Receiver fails",
+			responseStatus: http.StatusServiceUnavailable,
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody []byte
+			var sigErr error
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotBody, _ = io.ReadAll(r.Body)
+				sigErr = auth.VerifyWebhookSignature(r.Header.Get(SignatureHeader), gotBody, []string{secret}, time.Minute, time.Now())
+				w.WriteHeader(tt.responseStatus)
+			}))
+			defer receiver.Close()
+
+			w := NewWorker(nil, nil)
+			w.AllowPrivateAddresses = true
+			statusCode, err := w.send(context.Background(), receiver.URL, secret, uuid.New(), payload)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if statusCode != tt.responseStatus {
+				t.Errorf("send() status = %v, want %v", statusCode, tt.responseStatus)
+			}
+			if string(gotBody) != string(payload) {
+				t.Errorf("receiver got body %s, want %s", gotBody, payload)
+			}
+			if sigErr != nil {
+				t.Errorf("receiver couldn't verify signature: %v", sigErr)
+			}
+		})
+	}
+}
+
+func TestWorkerRefusesPrivateAddresses(t *testing.T) {
+	var delivered bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered = true
+	}))
+	defer receiver.Close()
+
+	w := NewWorker(nil, nil)
+	_, err := w.send(context.Background(), receiver.URL, "subscriber-secret", uuid.New(), []byte(`{}`))
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Errorf("send() error = %v, want %v", err, ErrPrivateAddress)
+	}
+	if delivered {
+		t.Error("receiver on a loopback address got the delivery")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int32
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 4, want: 4 * time.Minute},
+		{attempts: 20, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		got := Backoff(tt.attempts, 30*time.Second, time.Hour)
+		if got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
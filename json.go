@@ -3,8 +3,10 @@ package main
 import (
 	"encoding/json"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+
+	"github.com/CybrRonin/Chirpy/internal/logging"
 )
 
 func decodeJSON(src io.Reader, params any) error {
@@ -13,13 +15,14 @@ func decodeJSON(src io.Reader, params any) error {
 	return decoder.Decode(params)
 }
 
-func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
-	if err != nil {
-		log.Println(err)
-	}
-
+// respondWithError writes an error response and logs err, if any, with the
+// request's logger. 5XX responses are logged as errors.
+func respondWithError(w http.ResponseWriter, req *http.Request, code int, msg string, err error) {
+	logger := logging.FromContext(req.Context())
 	if code > 499 {
-		log.Printf("Responding with 5XX error: %s", msg)
+		logger.ErrorContext(req.Context(), "responding with 5XX error", "status", code, "message", msg, "error", err)
+	} else if err != nil {
+		logger.InfoContext(req.Context(), "request failed", "status", code, "message", msg, "error", err)
 	}
 
 	type errorResponse struct {
@@ -33,7 +36,7 @@ func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	dat, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %s", err)
+		slog.Error("couldn't marshal JSON response", "error", err)
 		w.WriteHeader(500)
 		return
 	}
@@ -0,0 +1,13 @@
+package main
+
+import "net/http"
+
+// accessLogUserID identifies the caller in access logs. It only checks the
+// access token, so suspended users are still attributed.
+func (cfg *apiConfig) accessLogUserID(req *http.Request) string {
+	userID, ok := cfg.authenticatedUserID(req)
+	if !ok {
+		return ""
+	}
+	return userID.String()
+}
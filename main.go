@@ -1,30 +1,70 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"sync"
 	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/CybrRonin/Chirpy/internal/auth"
+	"github.com/CybrRonin/Chirpy/internal/config"
+	"github.com/CybrRonin/Chirpy/internal/contentfilter"
 	"github.com/CybrRonin/Chirpy/internal/database"
-	"github.com/joho/godotenv"
+	"github.com/CybrRonin/Chirpy/internal/entitlements"
+	"github.com/CybrRonin/Chirpy/internal/logging"
+	"github.com/CybrRonin/Chirpy/internal/metrics"
+	"github.com/CybrRonin/Chirpy/internal/ratelimit"
+	"github.com/CybrRonin/Chirpy/internal/realtime"
+	"github.com/CybrRonin/Chirpy/internal/tracing"
+	"github.com/CybrRonin/Chirpy/internal/webhooks"
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
-	fileserverHits atomic.Int32
-	db             *database.Queries
-	platform       string
-	jwtSecret      string
-	polkaKey       string
+	fileserverHits          atomic.Int32
+	db                      database.Querier
+	dbConn                  *sql.DB
+	withTx                  txRunner
+	platform                string
+	jwtSecret               string
+	polkaKey                string
+	polkaSecrets            []string
+	polkaAllowLegacyKey     bool
+	polkaSignatureTolerance time.Duration
+	hashParams              auth.HashParams
+	passwordPolicy          *auth.PasswordPolicy
+	rateLimiter             *ratelimit.Limiter
+	trustProxy              bool
+	hub                     *realtime.Hub
+	events                  realtime.Publisher
+	notifications           chan notificationJob
+	contentFilter           *contentfilter.Filter
+	contentFilterSource     contentfilter.Source
+	chirpRestoreWindow      time.Duration
+	metrics                 *metrics.Metrics
+	metricsToken            string
+	entitlementsCache       *entitlements.Cache
+	// draining is set once shutdown begins so readiness probes start failing
+	// and load balancers stop sending new traffic.
+	draining atomic.Bool
+	// streamsClosed is closed when shutdown begins so SSE and websocket
+	// streams end instead of holding the drain open until its deadline.
+	streamsClosed chan struct{}
 }
 
 func main() {
 	const (
-		port                  = "8080"
 		filepathRoot          = "."
-		filepathReadiness     = "/healthz"
+		filepathHealthz       = "/healthz"
+		filepathLivez         = "/livez"
+		filepathReadyz        = "/readyz"
 		filepathApp           = "/app"
 		filepathMetrics       = "/metrics"
 		filepathReset         = "/reset"
@@ -38,55 +78,201 @@ func main() {
 		filepathRevoke        = "/revoke"
 		filepathPolka         = "/polka"
 		filepathWebhooks      = "/webhooks"
+		filepathEvents        = "/events"
+		filepathSubscribers   = "/subscribers"
+		filepathStream        = "/stream"
+		filepathLikes         = "/likes"
+		filepathWebSocket     = "/ws"
+		filepathRechirps      = "/rechirps"
+		filepathFollow        = "/follow"
+		filepathNotifications = "/notifications"
+		filepathRead          = "/read"
+		filepathPreferences   = "/preferences"
+		filepathConversations = "/conversations"
+		filepathMessages      = "/messages"
+		filepathBlock         = "/block"
+		filepathMute          = "/mute"
+		filepathPrivacy       = "/privacy"
+		filepathFollowReqs    = "/follow-requests"
+		filepathAccept        = "/accept"
+		filepathReject        = "/reject"
+		filepathContentFilter = "/content-filter"
+		filepathWords         = "/words"
+		filepathReport        = "/report"
+		filepathReports       = "/reports"
+		filepathDismiss       = "/dismiss"
+		filepathHide          = "/hide"
+		filepathSuspend       = "/suspend"
+		filepathBan           = "/ban"
+		filepathRestore       = "/restore"
+		filepathAudit         = "/audit"
+		filepathModeration    = "/moderation"
+		filepathActions       = "/actions"
+
+		subscriptionExpiryInterval = time.Hour
+		webhookDeliveryInterval    = 5 * time.Second
+		chirpPurgeInterval         = time.Hour
+		entitlementsCacheTTL       = 30 * time.Second
+		// tracingFlushTimeout is how long shutdown waits to export the last
+		// spans, separate from the drain so a slow drain can't use it up.
+		tracingFlushTimeout = 5 * time.Second
 	)
 
-	godotenv.Load()
-	dbURL := os.Getenv("DB_URL")
-	if dbURL == "" {
-		log.Fatal("DB_URL must be set")
+	conf, err := config.Load()
+	if err != nil {
+		fatal("invalid configuration", "error", err)
+	}
+	logger := logging.New(os.Stdout, conf.LogLevel)
+	slog.SetDefault(logger)
+
+	dbConn, err := sql.Open("postgres", conf.DatabaseURL)
+	if err != nil {
+		fatal("error opening database", "error", err)
 	}
-	platform := os.Getenv("PLATFORM")
-	if platform == "" {
-		log.Fatal("PLATFORM must be set")
+
+	shutdownTracing, err := tracing.Setup(context.Background(), conf.Tracing)
+	if err != nil {
+		fatal("error setting up tracing", "error", err)
 	}
 
-	dbConn, err := sql.Open("postgres", dbURL)
+	passwordPolicy, err := auth.NewPasswordPolicy(conf.Passwords.MinLength, conf.Passwords.BlocklistFile)
 	if err != nil {
-		log.Fatalf("error opening database: %s", err)
+		fatal("error loading password policy", "error", err)
+	}
+
+	dbQueries := database.New(tracing.WrapDBTX(dbConn))
+
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if conf.RateLimit.Backend == "postgres" {
+		rateLimitStore = ratelimit.NewPostgresStore(dbQueries)
 	}
 
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		log.Fatal("JWT_SECRET environment variable is not set")
+	contentFilter := contentfilter.New(contentfilter.DefaultRules)
+	var contentFilterSource contentfilter.Source
+	switch conf.ContentFilter.Backend {
+	case "file":
+		contentFilterSource = contentfilter.FileSource{Path: conf.ContentFilter.File}
+	case "postgres":
+		contentFilterSource = contentFilterWordsFromDB(dbQueries)
+	}
+	if contentFilterSource != nil {
+		err = contentFilter.Reload(context.Background(), contentFilterSource)
+		if err != nil {
+			fatal("couldn't load content filter", "error", err)
+		}
 	}
 
-	polkaKey := os.Getenv("POLKA_KEY")
-	if polkaKey == "" {
-		log.Fatal("POLKA_KEY environment variable is not set")
+	// Background workers run until shutdown, which cancels workerCtx and
+	// waits for them before closing the database pool.
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+
+	hub := realtime.NewHub()
+	var events realtime.Publisher = hub
+	if conf.RealtimeBackend == "postgres" {
+		events = realtime.NewPostgresPublisher(dbQueries)
+		workers.Go(func() {
+			err := realtime.ListenPostgres(workerCtx, conf.DatabaseURL, hub)
+			if err != nil && workerCtx.Err() == nil {
+				slog.Error("realtime listener stopped", "error", err)
+			}
+		})
 	}
 
+	serverMetrics := metrics.New(dbConn)
+
 	apiCfg := apiConfig{
 		fileserverHits: atomic.Int32{},
-		db:             database.New(dbConn),
-		platform:       platform,
-		jwtSecret:      secret,
-		polkaKey:       polkaKey,
+		db:             dbQueries,
+		dbConn:         dbConn,
+		withTx:         sqlTxRunner(dbConn),
+		platform:       conf.Platform,
+		jwtSecret:      conf.JWTSecret,
+		polkaKey:       conf.Polka.Key,
+		polkaSecrets:   conf.Polka.WebhookSecrets,
+
+		polkaAllowLegacyKey:     conf.Polka.AllowLegacyKey,
+		polkaSignatureTolerance: conf.Polka.SignatureTolerance,
+		hashParams:              conf.Passwords.Hash,
+		passwordPolicy:          passwordPolicy,
+		rateLimiter: &ratelimit.Limiter{
+			Store:        rateLimitStore,
+			DefaultLimit: conf.RateLimit.Default,
+			RouteLimits:  conf.RateLimit.Routes,
+		},
+		trustProxy: conf.TrustProxy,
+		hub:        hub,
+		events:     events,
+
+		notifications: make(chan notificationJob, notificationQueueSize),
+
+		contentFilter:       contentFilter,
+		contentFilterSource: contentFilterSource,
+		chirpRestoreWindow:  conf.Chirps.RestoreWindow,
+		metrics:             serverMetrics,
+		metricsToken:        conf.MetricsToken,
+		streamsClosed:       make(chan struct{}),
+	}
+	apiCfg.entitlementsCache = &entitlements.Cache{
+		TTL:  entitlementsCacheTTL,
+		Load: apiCfg.entitlementsFor,
 	}
 
 	mux := http.NewServeMux()
 	fsHandler := apiCfg.middlewareMetricsInc(http.StripPrefix(filepathApp, http.FileServer(http.Dir(filepathRoot))))
 	mux.Handle(filepathApp+"/", fsHandler)
 
-	mux.HandleFunc("GET "+filepathApi+filepathReadiness, handlerReadiness)
+	mux.HandleFunc("GET "+filepathApi+filepathLivez, handlerLiveness)
+	mux.HandleFunc("GET "+filepathApi+filepathReadyz, apiCfg.handlerReadiness)
+	// Kept for probes configured before livez and readyz existed.
+	mux.HandleFunc("GET "+filepathApi+filepathHealthz, apiCfg.handlerReadiness)
+	mux.HandleFunc("GET "+filepathMetrics, apiCfg.handlerPrometheusMetrics)
 
 	mux.HandleFunc("POST "+filepathApi+filepathUsers, apiCfg.handlerUsersCreate)
 	mux.HandleFunc("PUT "+filepathApi+filepathUsers, apiCfg.handlerUsersUpdate)
+	mux.HandleFunc("PUT "+filepathApi+filepathUsers+filepathPrivacy, apiCfg.handlerUsersPrivacy)
 	mux.HandleFunc("POST "+filepathApi+filepathLogin, apiCfg.handlerLogin)
 
 	mux.HandleFunc("POST "+filepathApi+filepathChirps, apiCfg.handlerChirpsCreate)
 	mux.HandleFunc("GET "+filepathApi+filepathChirps, apiCfg.handlerChirpsGetAll)
+	mux.HandleFunc("GET "+filepathApi+filepathChirps+filepathStream, apiCfg.handlerChirpsStream)
 	mux.HandleFunc("GET "+filepathApi+filepathChirps+"/{chirpID}", apiCfg.handlerChirpsGet)
+	mux.HandleFunc("PUT "+filepathApi+filepathChirps+"/{chirpID}", apiCfg.handlerChirpsUpdate)
 	mux.HandleFunc("DELETE "+filepathApi+filepathChirps+"/{chirpID}", apiCfg.handlerChirpsDelete)
+	mux.HandleFunc("POST "+filepathApi+filepathChirps+"/{chirpID}"+filepathRestore, apiCfg.handlerChirpsRestore)
+
+	mux.HandleFunc("POST "+filepathApi+filepathChirps+"/{chirpID}"+filepathLikes, apiCfg.handlerLikesCreate)
+	mux.HandleFunc("DELETE "+filepathApi+filepathChirps+"/{chirpID}"+filepathLikes, apiCfg.handlerLikesDelete)
+
+	mux.HandleFunc("POST "+filepathApi+filepathChirps+"/{chirpID}"+filepathReport, apiCfg.handlerReportsCreate)
+
+	mux.HandleFunc("POST "+filepathApi+filepathChirps+"/{chirpID}"+filepathRechirps, apiCfg.handlerRechirpsCreate)
+	mux.HandleFunc("DELETE "+filepathApi+filepathChirps+"/{chirpID}"+filepathRechirps, apiCfg.handlerRechirpsDelete)
+
+	mux.HandleFunc("POST "+filepathApi+filepathUsers+"/{userID}"+filepathFollow, apiCfg.handlerFollowsCreate)
+	mux.HandleFunc("DELETE "+filepathApi+filepathUsers+"/{userID}"+filepathFollow, apiCfg.handlerFollowsDelete)
+	mux.HandleFunc("GET "+filepathApi+filepathFollowReqs, apiCfg.handlerFollowRequestsList)
+	mux.HandleFunc("POST "+filepathApi+filepathFollowReqs+"/{userID}"+filepathAccept, apiCfg.handlerFollowRequestsAccept)
+	mux.HandleFunc("POST "+filepathApi+filepathFollowReqs+"/{userID}"+filepathReject, apiCfg.handlerFollowRequestsReject)
+	mux.HandleFunc("POST "+filepathApi+filepathUsers+"/{userID}"+filepathBlock, apiCfg.handlerBlocksCreate)
+	mux.HandleFunc("DELETE "+filepathApi+filepathUsers+"/{userID}"+filepathBlock, apiCfg.handlerBlocksDelete)
+	mux.HandleFunc("POST "+filepathApi+filepathUsers+"/{userID}"+filepathMute, apiCfg.handlerMutesCreate)
+	mux.HandleFunc("DELETE "+filepathApi+filepathUsers+"/{userID}"+filepathMute, apiCfg.handlerMutesDelete)
+
+	mux.HandleFunc("GET "+filepathApi+filepathNotifications, apiCfg.handlerNotificationsList)
+	mux.HandleFunc("POST "+filepathApi+filepathNotifications+filepathRead, apiCfg.handlerNotificationsRead)
+	mux.HandleFunc("GET "+filepathApi+filepathNotifications+filepathPreferences, apiCfg.handlerNotificationPreferencesGet)
+	mux.HandleFunc("PUT "+filepathApi+filepathNotifications+filepathPreferences, apiCfg.handlerNotificationPreferencesUpdate)
+
+	mux.HandleFunc("POST "+filepathApi+filepathConversations, apiCfg.handlerConversationsCreate)
+	mux.HandleFunc("GET "+filepathApi+filepathConversations, apiCfg.handlerConversationsList)
+	mux.HandleFunc("GET "+filepathApi+filepathConversations+"/{conversationID}"+filepathMessages, apiCfg.handlerMessagesList)
+	mux.HandleFunc("POST "+filepathApi+filepathConversations+"/{conversationID}"+filepathMessages, apiCfg.handlerMessagesCreate)
+	mux.HandleFunc("POST "+filepathApi+filepathConversations+"/{conversationID}"+filepathRead, apiCfg.handlerConversationsRead)
+	mux.HandleFunc("POST "+filepathApi+filepathConversations+"/{conversationID}"+filepathBlock, apiCfg.handlerConversationsBlock)
+	mux.HandleFunc("DELETE "+filepathApi+filepathConversations+"/{conversationID}"+filepathBlock, apiCfg.handlerConversationsUnblock)
+
+	mux.HandleFunc("GET "+filepathApi+filepathWebSocket, apiCfg.handlerWebSocket)
 
 	mux.HandleFunc("POST "+filepathApi+filepathRefresh, apiCfg.handlerRefreshTokensRefresh)
 	mux.HandleFunc("POST "+filepathApi+filepathRevoke, apiCfg.handlerRefreshTokensRevoke)
@@ -94,13 +280,112 @@ func main() {
 	mux.HandleFunc("GET "+filepathAdmin+filepathMetrics, apiCfg.handlerMetrics)
 	mux.HandleFunc("POST "+filepathAdmin+filepathReset, apiCfg.handlerReset)
 
-	mux.HandleFunc("POST "+filepathApi+filepathPolka+filepathWebhooks, apiCfg.handlerUpgradeUser)
+	mux.HandleFunc("POST "+filepathApi+filepathPolka+filepathWebhooks, apiCfg.handlerPolkaWebhooks)
+	mux.HandleFunc("POST "+filepathApi+filepathWebhooks+filepathSubscribers, apiCfg.handlerWebhookSubscribersCreate)
+	mux.HandleFunc("GET "+filepathApi+filepathWebhooks+filepathSubscribers, apiCfg.handlerWebhookSubscribersList)
+	mux.HandleFunc("DELETE "+filepathApi+filepathWebhooks+filepathSubscribers+"/{subscriberID}", apiCfg.handlerWebhookSubscribersDelete)
+	mux.HandleFunc("GET "+filepathApi+filepathWebhooks+filepathSubscribers+"/{subscriberID}/deliveries", apiCfg.handlerWebhookDeliveriesList)
+
+	mux.HandleFunc("GET "+filepathAdmin+filepathContentFilter+filepathWords, apiCfg.handlerContentFilterWordsList)
+	mux.HandleFunc("PUT "+filepathAdmin+filepathContentFilter+filepathWords, apiCfg.handlerContentFilterWordsUpsert)
+	mux.HandleFunc("DELETE "+filepathAdmin+filepathContentFilter+filepathWords+"/{word}", apiCfg.handlerContentFilterWordsDelete)
+
+	mux.HandleFunc("GET "+filepathAdmin+filepathReports, apiCfg.handlerReportsList)
+	mux.HandleFunc("POST "+filepathAdmin+filepathReports+"/{reportID}"+filepathDismiss, apiCfg.handlerReportsDismiss)
+	mux.HandleFunc("POST "+filepathAdmin+filepathChirps+"/{chirpID}"+filepathHide, apiCfg.handlerChirpsHide)
+	mux.HandleFunc("DELETE "+filepathAdmin+filepathChirps+"/{chirpID}"+filepathHide, apiCfg.handlerChirpsUnhide)
+	mux.HandleFunc("DELETE "+filepathAdmin+filepathChirps+"/{chirpID}", apiCfg.handlerChirpsRemove)
+	mux.HandleFunc("POST "+filepathAdmin+filepathUsers+"/{userID}"+filepathSuspend, apiCfg.handlerUsersSuspend)
+	mux.HandleFunc("DELETE "+filepathAdmin+filepathUsers+"/{userID}"+filepathSuspend, apiCfg.handlerUsersUnsuspend)
+	mux.HandleFunc("POST "+filepathAdmin+filepathUsers+"/{userID}"+filepathBan, apiCfg.handlerUsersBan)
+	mux.HandleFunc("DELETE "+filepathAdmin+filepathUsers+"/{userID}"+filepathBan, apiCfg.handlerUsersUnban)
+	mux.HandleFunc("GET "+filepathAdmin+filepathModeration+filepathActions, apiCfg.handlerModerationLog)
+	mux.HandleFunc("GET "+filepathAdmin+filepathAudit, apiCfg.handlerAuditList)
+
+	mux.HandleFunc("GET "+filepathAdmin+filepathWebhooks+filepathEvents, apiCfg.handlerWebhookEventsList)
+	mux.HandleFunc("POST "+filepathAdmin+filepathWebhooks+filepathEvents+"/{eventID}/replay", apiCfg.handlerWebhookEventsReplay)
+
+	workers.Go(func() { apiCfg.runSubscriptionExpiry(workerCtx, subscriptionExpiryInterval) })
+	workers.Go(func() { apiCfg.runNotifications(workerCtx) })
+	workers.Go(func() { apiCfg.runChirpPurge(workerCtx, chirpPurgeInterval, conf.Chirps.PurgeAfter) })
+	if contentFilterSource != nil {
+		workers.Go(func() { contentFilter.Watch(workerCtx, contentFilterSource, conf.ContentFilter.ReloadInterval) })
+	}
+	webhookWorker := webhooks.NewWorker(dbConn, dbQueries)
+	webhookWorker.OnAttempt = serverMetrics.ObserveWebhookDelivered
+	webhookWorker.AllowPrivateAddresses = conf.Platform == config.PlatformDev
+	workers.Go(func() { webhookWorker.Run(workerCtx, webhookDeliveryInterval) })
+
+	// Logging wraps every other layer so they all see the request's logger.
+	// Authentication wraps even that so the access log can name the caller,
+	// and metrics are outermost so every response and its full latency are
+	// counted.
+	var handler http.Handler = apiCfg.middlewareRateLimit(mux)
+	handler = tracing.Middleware(mux, handler)
+	handler = logging.Middleware(logger, mux, apiCfg.accessLogUserID, handler)
+	handler = apiCfg.middlewareAuthenticate(handler)
+	handler = serverMetrics.Middleware(mux, handler)
 
 	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: mux,
+		Addr:              ":" + strconv.Itoa(conf.Port),
+		Handler:           handler,
+		ReadTimeout:       conf.Server.ReadTimeout,
+		ReadHeaderTimeout: conf.Server.ReadHeaderTimeout,
+		WriteTimeout:      conf.Server.WriteTimeout,
+		IdleTimeout:       conf.Server.IdleTimeout,
+		MaxHeaderBytes:    conf.Server.MaxHeaderBytes,
+	}
+	srv.RegisterOnShutdown(apiCfg.closeStreams)
+
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("serving", "port", conf.Port)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serveErr:
+		// fatal exits without running deferred calls, so flush spans first.
+		shutdownTracing(context.Background())
+		fatal("server stopped", "error", err)
+	case <-signalCtx.Done():
 	}
+	// A second signal kills the process without waiting for the drain.
+	stopSignals()
+
+	slog.Info("shutting down", "delay", conf.Server.ShutdownDelay.String(), "timeout", conf.Server.ShutdownTimeout.String())
+	apiCfg.draining.Store(true)
+	time.Sleep(conf.Server.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), conf.Server.ShutdownTimeout)
+	defer cancel()
+	err = srv.Shutdown(shutdownCtx)
+	if err != nil {
+		slog.Error("requests still in flight at the drain deadline", "error", err)
+		srv.Close()
+	}
+
+	stopWorkers()
+	workers.Wait()
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), tracingFlushTimeout)
+	defer cancelFlush()
+	err = shutdownTracing(flushCtx)
+	if err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
+	err = dbConn.Close()
+	if err != nil {
+		slog.Error("failed to close database", "error", err)
+	}
+	slog.Info("shutdown complete")
+}
 
-	log.Printf("Serving on port: %s\n", port)
-	log.Fatal(srv.ListenAndServe())
+// fatal logs msg at error level and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
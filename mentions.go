@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+// Users don't have handles, so chirps mention them by email: "hi @alice@example.com".
+var mentionPattern = regexp.MustCompile(`@([A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+func mentionedEmails(body string) []string {
+	var emails []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		emails = append(emails, match[1])
+	}
+	return emails
+}
+
+// createMentions records the users a chirp mentions and returns their IDs.
+// Mentions of addresses that don't belong to a user, or of users who have
+// blocked the author, are ignored.
+func createMentions(ctx context.Context, q database.Querier, chirp database.Chirp) ([]uuid.UUID, error) {
+	emails := mentionedEmails(chirp.Body)
+	if len(emails) == 0 {
+		return nil, nil
+	}
+
+	users, err := q.GetMentionableUsers(ctx, database.GetMentionableUsersParams{
+		Emails:   emails,
+		AuthorID: chirp.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mentioned := make([]uuid.UUID, 0, len(users))
+	for _, user := range users {
+		err := q.CreateChirpMention(ctx, database.CreateChirpMentionParams{
+			ChirpID: chirp.ID,
+			UserID:  user.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		mentioned = append(mentioned, user.ID)
+	}
+	return mentioned, nil
+}
@@ -1,10 +1,28 @@
 package main
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
+
+	"github.com/CybrRonin/Chirpy/internal/auth"
 )
 
+// handlerPrometheusMetrics serves the Prometheus metrics. When METRICS_TOKEN
+// is set, scrapers must send it as a bearer token.
+func (cfg *apiConfig) handlerPrometheusMetrics(w http.ResponseWriter, req *http.Request) {
+	if cfg.metricsToken != "" {
+		token, err := auth.GetBearerToken(req.Header)
+		if err != nil || subtle.ConstantTimeCompare([]byte(token), []byte(cfg.metricsToken)) != 1 {
+			respondWithError(w, req, http.StatusUnauthorized, "invalid metrics token", err)
+			return
+		}
+	}
+	cfg.metrics.Handler().ServeHTTP(w, req)
+}
+
+// handlerMetrics is the admin dashboard. It predates the Prometheus endpoint
+// and only shows the fileserver hit count.
 func (cfg *apiConfig) handlerMetrics(w http.ResponseWriter, req *http.Request) {
 	w.Header().Add("Content-Type", "text/html")
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/realtime"
+	"github.com/google/uuid"
+)
+
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Type      string     `json:"type"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty"`
+	ChirpID   *uuid.UUID `json:"chirp_id,omitempty"`
+	Read      bool       `json:"read"`
+}
+
+const (
+	notificationTypeReply          = "reply"
+	notificationTypeMention        = "mention"
+	notificationTypeFollow         = "follow"
+	notificationTypeFollowRequest  = "follow_request"
+	notificationTypeFollowAccepted = "follow_accepted"
+	notificationTypeLike           = "like"
+	notificationTypeRechirp        = "rechirp"
+	notificationTypeUpgrade        = "upgrade"
+
+	eventNotificationCreated = "notification.created"
+
+	notificationQueueSize = 1024
+	// notificationDrainTimeout bounds how long shutdown waits for queued
+	// notifications to be written.
+	notificationDrainTimeout = 10 * time.Second
+)
+
+var notificationTypes = []string{
+	notificationTypeReply,
+	notificationTypeMention,
+	notificationTypeFollow,
+	notificationTypeFollowRequest,
+	notificationTypeFollowAccepted,
+	notificationTypeLike,
+	notificationTypeRechirp,
+	notificationTypeUpgrade,
+}
+
+// notificationJob describes one event to fan out. ActorID and ChirpID are
+// left as uuid.Nil when the event has no actor (Polka) or no chirp (follows).
+type notificationJob struct {
+	Type       string
+	ActorID    uuid.UUID
+	Recipients []uuid.UUID
+	ChirpID    uuid.UUID
+}
+
+// notify queues a job for the notification worker without blocking the
+// request. Notifications are best-effort: when the queue is full the job is
+// dropped rather than slowing down the handler.
+func (cfg *apiConfig) notify(job notificationJob) {
+	if len(job.Recipients) == 0 {
+		return
+	}
+	select {
+	case cfg.notifications <- job:
+	default:
+		slog.Warn("notification queue full, dropping notification", "type", job.Type)
+		cfg.metrics.ObserveNotificationsDropped(1)
+	}
+}
+
+// runNotifications writes queued notifications and pushes them to the
+// recipients' realtime notification topics until ctx is cancelled, then
+// drains whatever is still queued.
+func (cfg *apiConfig) runNotifications(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			cfg.drainNotifications(context.WithoutCancel(ctx), notificationDrainTimeout)
+			return
+		case job := <-cfg.notifications:
+			if ctx.Err() != nil {
+				// Shutdown began as this job came off the queue.
+				cfg.drainNotifications(context.WithoutCancel(ctx), notificationDrainTimeout, job)
+				return
+			}
+			cfg.deliverNotification(ctx, job)
+		}
+	}
+}
+
+// drainNotifications delivers pending and then queued jobs until the queue is
+// empty or timeout passes, and counts the ones left over as dropped. It runs
+// once the server has stopped taking requests, so nothing new should be
+// queued meanwhile.
+func (cfg *apiConfig) drainNotifications(ctx context.Context, timeout time.Duration, pending ...notificationJob) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for _, job := range pending {
+		cfg.deliverNotification(ctx, job)
+	}
+	for {
+		select {
+		case job := <-cfg.notifications:
+			if ctx.Err() == nil {
+				cfg.deliverNotification(ctx, job)
+				continue
+			}
+			dropped := 1 + len(cfg.notifications)
+			slog.Warn("notification drain timed out, dropping notifications", "count", dropped)
+			cfg.metrics.ObserveNotificationsDropped(dropped)
+			return
+		default:
+			return
+		}
+	}
+}
+
+func (cfg *apiConfig) deliverNotification(ctx context.Context, job notificationJob) {
+	seen := map[uuid.UUID]bool{}
+	for _, recipient := range job.Recipients {
+		// Nobody needs to be told about their own actions.
+		if recipient == job.ActorID || seen[recipient] {
+			continue
+		}
+		seen[recipient] = true
+
+		n, err := cfg.db.CreateNotification(ctx, database.CreateNotificationParams{
+			UserID:  recipient,
+			ActorID: uuid.NullUUID{UUID: job.ActorID, Valid: job.ActorID != uuid.Nil},
+			Type:    job.Type,
+			ChirpID: uuid.NullUUID{UUID: job.ChirpID, Valid: job.ChirpID != uuid.Nil},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// The recipient has turned this notification type off.
+			continue
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to create notification", "type", job.Type, "recipient", recipient, "error", err)
+			continue
+		}
+
+		cfg.publishEvent(ctx, eventNotificationCreated, recipient, []string{realtime.NotificationsTopic(recipient)}, mapNotification(n))
+	}
+}
+
+func mapNotification(n database.Notification) Notification {
+	notification := Notification{
+		ID:        n.ID,
+		CreatedAt: n.CreatedAt,
+		Type:      n.Type,
+		Read:      n.ReadAt.Valid,
+	}
+	if n.ActorID.Valid {
+		notification.ActorID = &n.ActorID.UUID
+	}
+	if n.ChirpID.Valid {
+		notification.ChirpID = &n.ChirpID.UUID
+	}
+	return notification
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNotifyDropsWhenQueueFull(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+	cfg.notifications = make(chan notificationJob, 1)
+
+	job := notificationJob{Type: notificationTypeFollow, ActorID: uuid.New(), Recipients: []uuid.UUID{uuid.New()}}
+	cfg.notify(job)
+	cfg.notify(job)
+
+	if got := len(cfg.notifications); got != 1 {
+		t.Errorf("queue holds %d jobs, want 1", got)
+	}
+}
+
+func TestRunNotificationsDrainsQueueOnShutdown(t *testing.T) {
+	db := newFakeDB()
+	cfg := newTestConfig(t, db)
+
+	for range 3 {
+		cfg.notify(notificationJob{Type: notificationTypeFollow, ActorID: uuid.New(), Recipients: []uuid.UUID{uuid.New()}})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg.runNotifications(ctx)
+
+	if got := len(db.notifications); got != 3 {
+		t.Errorf("got %d notifications after shutdown, want 3", got)
+	}
+	if got := len(cfg.notifications); got != 0 {
+		t.Errorf("%d jobs left in the queue, want 0", got)
+	}
+}
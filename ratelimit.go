@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/CybrRonin/Chirpy/internal/logging"
+	"github.com/CybrRonin/Chirpy/internal/ratelimit"
+)
+
+// middlewareRateLimit wraps the whole mux so every route gets the limit
+// configured for its pattern. Requests are keyed by user ID when they carry a
+// valid access token and by client IP otherwise, and a user's limit is scaled
+// by their plan, which is cached briefly rather than looked up on every
+// request.
+func (cfg *apiConfig) middlewareRateLimit(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, route := mux.Handler(req)
+		limit := cfg.rateLimiter.LimitFor(route)
+		if route == "" || limit.IsZero() {
+			mux.ServeHTTP(w, req)
+			return
+		}
+
+		identity := "ip:" + clientIP(req, cfg.trustProxy)
+		if userID, ok := cfg.authenticatedUserID(req); ok {
+			identity = "user:" + userID.String()
+			// Paid plans get proportionally larger buckets.
+			if ent, err := cfg.entitlementsCache.Get(req.Context(), userID); err == nil {
+				limit.Requests *= ent.RateLimitMultiplier
+			}
+		}
+
+		res, err := cfg.rateLimiter.Take(req.Context(), route, identity, limit)
+		if err != nil {
+			// Fail open: an unavailable backend shouldn't take the API down with it.
+			logging.FromContext(req.Context()).WarnContext(req.Context(), "rate limiter unavailable", "error", err)
+			mux.ServeHTTP(w, req)
+			return
+		}
+
+		setRateLimitHeaders(w.Header(), res)
+		if !res.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter.Seconds())))
+			respondWithError(w, req, http.StatusTooManyRequests, "Rate limit exceeded", nil)
+			return
+		}
+
+		mux.ServeHTTP(w, req)
+	})
+}
+
+func setRateLimitHeaders(h http.Header, res ratelimit.Result) {
+	h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.ResetAfter.Seconds())))
+}
+
+func ceilSeconds(s float64) int {
+	return int(math.Ceil(s))
+}
+
+// clientIP returns the address of the client. X-Forwarded-For is only
+// honoured when we're deployed behind a proxy we trust to set it, and then
+// only its last entry, which that proxy appended. Anything before it came
+// from the client and can be forged.
+func clientIP(req *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if values := req.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			forwarded := values[len(values)-1]
+			if i := strings.LastIndex(forwarded, ","); i >= 0 {
+				forwarded = forwarded[i+1:]
+			}
+			if last := strings.TrimSpace(forwarded); last != "" {
+				return last
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
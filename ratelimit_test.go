@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		forwarded  []string
+		trustProxy bool
+		want       string
+	}{
+		{
+			name: "No proxy",
+			want: "10.0.0.1",
+		},
+		{
+			name:      "Untrusted X-Forwarded-For is ignored",
+			forwarded: []string{"203.0.113.7"},
+			want:      "10.0.0.1",
+		},
+		{
+			name:       "Proxy appends the client address",
+			forwarded:  []string{"203.0.113.7"},
+			trustProxy: true,
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Forged leading entry is ignored",
+			forwarded:  []string{"198.51.100.1, 203.0.113.7"},
+			trustProxy: true,
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "Forged header line before the proxy's",
+			forwarded:  []string{"198.51.100.1", "203.0.113.7"},
+			trustProxy: true,
+			want:       "203.0.113.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/chirps", nil)
+			req.RemoteAddr = "10.0.0.1:54321"
+			for _, v := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if got := clientIP(req, tt.trustProxy); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
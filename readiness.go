@@ -1,9 +1,122 @@
 package main
 
-import "net/http"
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
 
-func handlerReadiness(w http.ResponseWriter, req *http.Request) {
+// readinessTimeout bounds each readiness check so a hung database can't hold
+// probes open past the load balancer's own timeout.
+const readinessTimeout = 2 * time.Second
+
+const (
+	componentOK       = "ok"
+	componentFailing  = "failing"
+	componentDraining = "draining"
+)
+
+//go:embed sql/schema/*.sql
+var schemaFiles embed.FS
+
+// expectedSchemaVersion is the newest goose migration this binary was built with.
+var expectedSchemaVersion = latestSchemaVersion(schemaFiles)
+
+type componentStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type migrationStatus struct {
+	componentStatus
+	Current  int64 `json:"current"`
+	Expected int64 `json:"expected"`
+}
+
+type readinessResponse struct {
+	Status     string `json:"status"`
+	Components struct {
+		Server     componentStatus `json:"server"`
+		Database   componentStatus `json:"database"`
+		Migrations migrationStatus `json:"migrations"`
+	} `json:"components"`
+}
+
+// handlerLiveness only reports that the process is serving requests. It
+// deliberately ignores dependencies so a database outage doesn't get every
+// instance restarted at once.
+func handlerLiveness(w http.ResponseWriter, req *http.Request) {
 	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(http.StatusText(http.StatusOK)))
 }
+
+// handlerReadiness reports whether this instance should receive traffic. It
+// fails while the server is draining, when the database doesn't answer, and
+// when the schema is behind the migrations this binary expects.
+func (cfg *apiConfig) handlerReadiness(w http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithTimeout(req.Context(), readinessTimeout)
+	defer cancel()
+
+	resp := readinessResponse{Status: componentOK}
+
+	resp.Components.Server.Status = componentOK
+	if cfg.draining.Load() {
+		resp.Components.Server.Status = componentDraining
+		resp.Status = componentFailing
+	}
+
+	resp.Components.Database.Status = componentOK
+	err := cfg.dbConn.PingContext(ctx)
+	if err != nil {
+		resp.Components.Database = componentStatus{Status: componentFailing, Error: err.Error()}
+		resp.Status = componentFailing
+	}
+
+	resp.Components.Migrations.Status = componentOK
+	resp.Components.Migrations.Expected = expectedSchemaVersion
+	current, err := cfg.db.GetSchemaVersion(ctx)
+	resp.Components.Migrations.Current = current
+	switch {
+	case err != nil:
+		resp.Components.Migrations.componentStatus = componentStatus{Status: componentFailing, Error: err.Error()}
+		resp.Status = componentFailing
+	case current < expectedSchemaVersion:
+		// A newer schema is fine: during a rolling deploy the new release
+		// migrates first while older instances are still serving.
+		resp.Components.Migrations.componentStatus = componentStatus{Status: componentFailing, Error: "database schema is behind"}
+		resp.Status = componentFailing
+	}
+
+	code := http.StatusOK
+	if resp.Status != componentOK {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, code, resp)
+}
+
+// latestSchemaVersion parses the numeric prefix of each migration file name,
+// the same way goose does, and returns the highest one.
+func latestSchemaVersion(fsys fs.FS) int64 {
+	names, err := fs.Glob(fsys, "sql/schema/*.sql")
+	if err != nil {
+		panic(err)
+	}
+
+	var latest int64
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(path.Base(name), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		latest = max(latest, version)
+	}
+	return latest
+}
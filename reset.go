@@ -1,19 +1,29 @@
 package main
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/CybrRonin/Chirpy/internal/config"
+)
 
 func (cfg *apiConfig) handlerReset(w http.ResponseWriter, req *http.Request) {
-	if cfg.platform != "dev" {
-		respondWithError(w, http.StatusForbidden, "Reset is only allowed in dev environment.", nil)
+	if cfg.platform != config.PlatformDev {
+		respondWithError(w, req, http.StatusForbidden, "Reset is only allowed in dev environment.", nil)
 		return
 	}
 
 	err := cfg.db.Reset(req.Context())
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to reset the database.", err)
+		respondWithError(w, req, http.StatusInternalServerError, "Failed to reset the database.", err)
 		return
 	}
 
+	event := cfg.newAuditEvent(req, auditDatabaseReset)
+	if userID, ok := cfg.authenticatedUserID(req); ok {
+		event.ActorID = userID
+	}
+	cfg.audit(req.Context(), event)
+
 	cfg.fileserverHits.Store(0)
 	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
@@ -0,0 +1,23 @@
+-- name: CreateAuditEvent :exec
+INSERT INTO audit_events (id, created_at, event_type, actor_id, target_id, ip, user_agent, payload)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+);
+
+-- name: ListAuditEvents :many
+-- Every filter is optional. Events are listed newest first.
+SELECT * FROM audit_events
+WHERE (sqlc.narg(event_type)::TEXT IS NULL OR event_type = sqlc.narg(event_type))
+AND (sqlc.narg(actor_id)::UUID IS NULL OR actor_id = sqlc.narg(actor_id))
+AND (sqlc.narg(target_id)::UUID IS NULL OR target_id = sqlc.narg(target_id))
+AND (sqlc.narg(since)::TIMESTAMP IS NULL OR created_at >= sqlc.narg(since))
+AND (sqlc.narg(until)::TIMESTAMP IS NULL OR created_at < sqlc.narg(until))
+ORDER BY created_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
@@ -0,0 +1,7 @@
+-- name: CreateChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT (chirp_id, user_id) DO NOTHING;
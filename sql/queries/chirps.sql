@@ -1,27 +1,73 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, visibility)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4
 )
 RETURNING *;
 
 -- name: GetAllChirps :many
+-- chirp_visible_to decides which chirps the viewer can see. Unlisted chirps
+-- are left out of listings except for their author, and deleted chirps are
+-- left out for everyone, authors included.
 SELECT * FROM chirps
+WHERE chirps.deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::UUID AND mutes.muted_id = chirps.user_id
+)
+AND (chirps.visibility <> 'unlisted' OR chirps.user_id = sqlc.narg(viewer_id)::UUID)
+AND chirp_visible_to(chirps.id, sqlc.narg(viewer_id)::UUID)
 ORDER BY created_at ASC;
 
 -- name: GetChirp :one
 SELECT * FROM chirps
-WHERE id = $1;
+WHERE id = sqlc.arg(id)
+AND chirps.deleted_at IS NULL
+AND chirp_visible_to(chirps.id, sqlc.narg(viewer_id)::UUID);
 
 -- name: GetChirpsByAuthor :many
+-- Like GetAllChirps, but for a single author.
 SELECT * FROM chirps
-WHERE user_id = $1
+WHERE user_id = sqlc.arg(user_id)
+AND chirps.deleted_at IS NULL
+AND NOT EXISTS (
+    SELECT 1 FROM mutes
+    WHERE mutes.muter_id = sqlc.narg(viewer_id)::UUID AND mutes.muted_id = chirps.user_id
+)
+AND (chirps.visibility <> 'unlisted' OR chirps.user_id = sqlc.narg(viewer_id)::UUID)
+AND chirp_visible_to(chirps.id, sqlc.narg(viewer_id)::UUID)
 ORDER BY created_at ASC;
 
 -- name: DeleteChirp :exec
+-- Deleted chirps stay in the trash until PurgeDeletedChirps removes them.
+UPDATE chirps
+SET deleted_at = NOW(), deleted_by = sqlc.arg(deleted_by), updated_at = NOW()
+WHERE id = sqlc.arg(id)
+AND deleted_at IS NULL;
+
+-- name: RestoreChirp :one
+-- Authors can only restore chirps they deleted themselves, and only while
+-- they're still within the restore window.
+UPDATE chirps
+SET deleted_at = NULL, deleted_by = NULL, updated_at = NOW()
+WHERE id = sqlc.arg(id)
+AND user_id = sqlc.arg(user_id)
+AND deleted_by = sqlc.arg(user_id)
+AND deleted_at > sqlc.arg(deleted_after)::TIMESTAMP
+RETURNING *;
+
+-- name: PurgeDeletedChirps :execrows
 DELETE FROM chirps
-WHERE id = $1;
+WHERE deleted_at < sqlc.arg(deleted_before)::TIMESTAMP;
+
+-- name: UpdateChirp :one
+UPDATE chirps
+SET body = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
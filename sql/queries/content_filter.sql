@@ -0,0 +1,19 @@
+-- name: ListContentFilterWords :many
+SELECT * FROM content_filter_words
+ORDER BY word ASC;
+
+-- name: UpsertContentFilterWord :one
+INSERT INTO content_filter_words (word, action, created_at, updated_at)
+VALUES (
+    $1,
+    $2,
+    NOW(),
+    NOW()
+)
+ON CONFLICT (word) DO UPDATE
+SET action = EXCLUDED.action, updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteContentFilterWord :execrows
+DELETE FROM content_filter_words
+WHERE word = $1;
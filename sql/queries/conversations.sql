@@ -0,0 +1,76 @@
+-- name: CreateConversation :one
+INSERT INTO conversations (id, created_at, updated_at)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW()
+)
+RETURNING *;
+
+-- name: AddConversationParticipant :exec
+INSERT INTO conversation_participants (conversation_id, user_id, joined_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+);
+
+-- name: FindDirectConversation :one
+SELECT * FROM conversations
+WHERE (
+    SELECT COUNT(*) FROM conversation_participants p
+    WHERE p.conversation_id = conversations.id
+) = 2
+AND EXISTS (
+    SELECT 1 FROM conversation_participants p
+    WHERE p.conversation_id = conversations.id AND p.user_id = sqlc.arg(user_id)
+)
+AND EXISTS (
+    SELECT 1 FROM conversation_participants p
+    WHERE p.conversation_id = conversations.id AND p.user_id = sqlc.arg(other_user_id)
+)
+LIMIT 1;
+
+-- name: GetConversation :one
+-- Conversation reads take the caller's ID and only return conversations the
+-- caller participates in.
+SELECT conversations.* FROM conversations
+JOIN conversation_participants p ON p.conversation_id = conversations.id
+WHERE conversations.id = sqlc.arg(id) AND p.user_id = sqlc.arg(user_id);
+
+-- name: ListConversations :many
+SELECT conversations.*, (
+    SELECT COUNT(*) FROM messages m
+    WHERE m.conversation_id = conversations.id
+    AND m.sender_id <> p.user_id
+    AND (p.last_read_at IS NULL OR m.created_at > p.last_read_at)
+)::BIGINT AS unread_count
+FROM conversations
+JOIN conversation_participants p ON p.conversation_id = conversations.id
+WHERE p.user_id = sqlc.arg(user_id)
+ORDER BY conversations.updated_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: ListConversationParticipants :many
+SELECT * FROM conversation_participants
+WHERE conversation_id = ANY(sqlc.arg(conversation_ids)::UUID[])
+AND conversation_id IN (
+    SELECT conversation_id FROM conversation_participants
+    WHERE user_id = sqlc.arg(user_id)
+)
+ORDER BY conversation_id, joined_at;
+
+-- name: MarkConversationRead :execrows
+UPDATE conversation_participants
+SET last_read_at = NOW()
+WHERE conversation_id = $1 AND user_id = $2;
+
+-- name: SetConversationBlocked :execrows
+UPDATE conversation_participants
+SET blocked_at = CASE WHEN sqlc.arg(blocked)::BOOLEAN THEN COALESCE(blocked_at, NOW()) ELSE NULL END
+WHERE conversation_id = sqlc.arg(conversation_id) AND user_id = sqlc.arg(user_id);
+
+-- name: TouchConversation :exec
+UPDATE conversations
+SET updated_at = NOW()
+WHERE id = $1;
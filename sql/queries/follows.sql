@@ -0,0 +1,33 @@
+-- name: FollowUser :one
+-- Following a protected account creates a pending request. No row means the
+-- follow or request already exists.
+INSERT INTO follows (follower_id, followee_id, created_at, status)
+SELECT sqlc.arg(follower_id)::UUID, users.id, NOW(), CASE WHEN users.protected THEN 'pending' ELSE 'accepted' END
+FROM users
+WHERE users.id = sqlc.arg(followee_id)::UUID
+ON CONFLICT (follower_id, followee_id) DO NOTHING
+RETURNING *;
+
+-- name: UnfollowUser :exec
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: ListFollowRequests :many
+SELECT * FROM follows
+WHERE followee_id = sqlc.arg(followee_id) AND status = 'pending'
+ORDER BY created_at ASC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: AcceptFollowRequest :execrows
+UPDATE follows
+SET status = 'accepted'
+WHERE follower_id = $1 AND followee_id = $2 AND status = 'pending';
+
+-- name: RejectFollowRequest :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2 AND status = 'pending';
+
+-- name: AcceptAllFollowRequests :execrows
+UPDATE follows
+SET status = 'accepted'
+WHERE followee_id = $1 AND status = 'pending';
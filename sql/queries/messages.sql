@@ -0,0 +1,26 @@
+-- name: CreateMessage :one
+-- Only participants can send, nobody can while any participant has blocked
+-- the conversation, and nobody can send to someone who has blocked them. No
+-- row means the message was refused.
+INSERT INTO messages (id, created_at, conversation_id, sender_id, body)
+SELECT gen_random_uuid(), NOW(), p.conversation_id, p.user_id, sqlc.arg(body)::TEXT
+FROM conversation_participants p
+WHERE p.conversation_id = sqlc.arg(conversation_id) AND p.user_id = sqlc.arg(sender_id)
+AND NOT EXISTS (
+    SELECT 1 FROM conversation_participants b
+    WHERE b.conversation_id = p.conversation_id AND b.blocked_at IS NOT NULL
+)
+AND NOT EXISTS (
+    SELECT 1 FROM conversation_participants o
+    JOIN blocks ON blocks.blocker_id = o.user_id AND blocks.blocked_id = p.user_id
+    WHERE o.conversation_id = p.conversation_id
+)
+RETURNING *;
+
+-- name: ListMessages :many
+SELECT messages.* FROM messages
+JOIN conversation_participants p
+    ON p.conversation_id = messages.conversation_id AND p.user_id = sqlc.arg(user_id)
+WHERE messages.conversation_id = sqlc.arg(conversation_id)
+ORDER BY messages.created_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
@@ -0,0 +1,65 @@
+-- name: GetChirpForModeration :one
+-- Unlike GetChirp, this ignores visibility so moderators can act on any chirp.
+SELECT * FROM chirps
+WHERE id = $1;
+
+-- name: SetChirpHidden :execrows
+UPDATE chirps
+SET hidden_at = CASE WHEN sqlc.arg(hidden)::BOOLEAN THEN COALESCE(hidden_at, NOW()) ELSE NULL END
+WHERE id = sqlc.arg(id);
+
+-- name: RemoveChirp :execrows
+-- A moderator's removal takes over a deletion that's already in the trash,
+-- so the author can't restore a chirp a moderator removed.
+UPDATE chirps
+SET deleted_at = COALESCE(deleted_at, NOW()), deleted_by = sqlc.arg(deleted_by), updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: SuspendUser :one
+UPDATE users
+SET suspended_until = $2, moderation_reason = $3, chirps_hidden = $4, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UnsuspendUser :one
+UPDATE users
+SET suspended_until = NULL,
+    moderation_reason = CASE WHEN banned_at IS NULL THEN '' ELSE moderation_reason END,
+    chirps_hidden = chirps_hidden AND banned_at IS NOT NULL,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: BanUser :one
+UPDATE users
+SET banned_at = COALESCE(banned_at, NOW()), moderation_reason = $2, chirps_hidden = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UnbanUser :one
+UPDATE users
+SET banned_at = NULL,
+    moderation_reason = CASE WHEN suspended_until > NOW() THEN moderation_reason ELSE '' END,
+    chirps_hidden = chirps_hidden AND COALESCE(suspended_until > NOW(), false),
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, created_at, moderator_id, action, chirp_id, user_id, report_id, note)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: ListModerationActions :many
+SELECT * FROM moderation_actions
+ORDER BY created_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
@@ -0,0 +1,84 @@
+-- name: CreateWebhookSubscriber :one
+INSERT INTO webhook_subscribers (id, created_at, updated_at, owner_id, url, secret, event_types)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: GetWebhookSubscriber :one
+SELECT * FROM webhook_subscribers
+WHERE id = $1;
+
+-- name: ListWebhookSubscribersByOwner :many
+SELECT * FROM webhook_subscribers
+WHERE owner_id = $1
+ORDER BY created_at ASC;
+
+-- name: DeleteWebhookSubscriber :exec
+DELETE FROM webhook_subscribers
+WHERE id = $1;
+
+-- name: CreateWebhookOutboxEvent :exec
+INSERT INTO webhook_outbox (id, created_at, event_type, payload)
+VALUES (
+    $1,
+    NOW(),
+    $2,
+    $3
+);
+
+-- name: ClaimUndispatchedOutboxEvents :many
+SELECT * FROM webhook_outbox
+WHERE dispatched_at IS NULL
+ORDER BY created_at ASC
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: CreateWebhookDeliveriesForEvent :execrows
+INSERT INTO webhook_deliveries (id, created_at, updated_at, subscriber_id, outbox_id, event_type, payload, status, next_attempt_at)
+SELECT gen_random_uuid(), NOW(), NOW(), s.id, o.id, o.event_type, o.payload, 'pending', NOW()
+FROM webhook_outbox o
+JOIN webhook_subscribers s ON s.active AND o.event_type = ANY(s.event_types)
+WHERE o.id = $1
+ON CONFLICT (subscriber_id, outbox_id) DO NOTHING;
+
+-- name: MarkOutboxEventDispatched :exec
+UPDATE webhook_outbox
+SET dispatched_at = NOW()
+WHERE id = $1;
+
+-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries d
+SET next_attempt_at = sqlc.arg(lease_until), updated_at = NOW()
+FROM webhook_subscribers s
+WHERE d.subscriber_id = s.id
+AND d.id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at ASC
+    LIMIT sqlc.arg(batch_size)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING d.id, d.event_type, d.payload, d.attempts, s.url, s.secret;
+
+-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+SET status = 'succeeded', attempts = attempts + 1, last_response_status = $2, last_error = NULL, delivered_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries
+SET status = $2, attempts = attempts + 1, next_attempt_at = $3, last_response_status = $4, last_error = $5, updated_at = NOW()
+WHERE id = $1;
+
+-- name: ListWebhookDeliveriesBySubscriber :many
+SELECT * FROM webhook_deliveries
+WHERE subscriber_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
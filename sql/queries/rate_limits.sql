@@ -0,0 +1,21 @@
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+VALUES (
+    sqlc.arg(key),
+    sqlc.arg(capacity)::DOUBLE PRECISION - 1,
+    TRUE,
+    NOW()
+)
+ON CONFLICT (key) DO UPDATE
+SET tokens = CASE
+        WHEN LEAST(sqlc.arg(capacity)::DOUBLE PRECISION, b.tokens + EXTRACT(EPOCH FROM (NOW() - b.updated_at))::DOUBLE PRECISION * sqlc.arg(rate)::DOUBLE PRECISION) >= 1
+        THEN LEAST(sqlc.arg(capacity)::DOUBLE PRECISION, b.tokens + EXTRACT(EPOCH FROM (NOW() - b.updated_at))::DOUBLE PRECISION * sqlc.arg(rate)::DOUBLE PRECISION) - 1
+        ELSE LEAST(sqlc.arg(capacity)::DOUBLE PRECISION, b.tokens + EXTRACT(EPOCH FROM (NOW() - b.updated_at))::DOUBLE PRECISION * sqlc.arg(rate)::DOUBLE PRECISION)
+    END,
+    allowed = LEAST(sqlc.arg(capacity)::DOUBLE PRECISION, b.tokens + EXTRACT(EPOCH FROM (NOW() - b.updated_at))::DOUBLE PRECISION * sqlc.arg(rate)::DOUBLE PRECISION) >= 1,
+    updated_at = NOW()
+RETURNING tokens, allowed;
+
+-- name: DeleteStaleRateLimitBuckets :exec
+DELETE FROM rate_limit_buckets
+WHERE updated_at < $1;
@@ -0,0 +1,5 @@
+-- name: NextRealtimeEventID :one
+SELECT nextval('realtime_event_id_seq')::BIGINT;
+
+-- name: NotifyRealtimeEvent :exec
+SELECT pg_notify('chirpy_realtime', sqlc.arg(payload)::TEXT);
@@ -0,0 +1,12 @@
+-- name: Rechirp :execrows
+INSERT INTO rechirps (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: Unrechirp :exec
+DELETE FROM rechirps
+WHERE user_id = $1 AND chirp_id = $2;
@@ -0,0 +1,57 @@
+-- name: CreateReport :one
+-- No row means the reporter has already reported this chirp.
+INSERT INTO reports (id, created_at, updated_at, chirp_id, reported_user_id, reporter_id, reason, details)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+ON CONFLICT (chirp_id, reporter_id) DO NOTHING
+RETURNING *;
+
+-- name: DismissFilterReports :execrows
+-- Closes the content filter's open report on a chirp that's been edited clean.
+UPDATE reports
+SET status = 'dismissed', resolved_at = NOW(), updated_at = NOW()
+WHERE chirp_id = $1 AND reporter_id IS NULL AND reason = 'filtered' AND status = 'open';
+
+-- name: FlagChirp :exec
+-- Files a report on behalf of the content filter, or updates the words on
+-- the chirp's open filter report if it already has one.
+INSERT INTO reports (id, created_at, updated_at, chirp_id, reported_user_id, reason, details)
+SELECT gen_random_uuid(), NOW(), NOW(), chirps.id, chirps.user_id, 'filtered', array_to_string(sqlc.arg(words)::TEXT[], ', ')
+FROM chirps
+WHERE chirps.id = sqlc.arg(chirp_id)
+ON CONFLICT (chirp_id) WHERE reporter_id IS NULL AND reason = 'filtered' AND status = 'open'
+DO UPDATE SET details = EXCLUDED.details, updated_at = NOW();
+
+-- name: GetReport :one
+SELECT * FROM reports
+WHERE id = $1;
+
+-- name: ListReports :many
+SELECT * FROM reports
+WHERE status = sqlc.arg(status)
+ORDER BY created_at ASC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: ResolveReport :one
+UPDATE reports
+SET status = $2, resolved_by = $3, resolved_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'open'
+RETURNING *;
+
+-- name: ResolveReportsForChirp :execrows
+UPDATE reports
+SET status = 'actioned', resolved_by = $2, resolved_at = NOW(), updated_at = NOW()
+WHERE chirp_id = $1 AND status = 'open';
+
+-- name: ResolveReportsForUser :execrows
+UPDATE reports
+SET status = 'actioned', resolved_by = $2, resolved_at = NOW(), updated_at = NOW()
+WHERE reported_user_id = $1 AND status = 'open';
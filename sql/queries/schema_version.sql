@@ -0,0 +1,9 @@
+-- name: GetSchemaVersion :one
+-- The current goose version: the highest version whose most recent entry is
+-- an apply rather than a rollback.
+SELECT COALESCE(MAX(v.version_id), 0)::BIGINT AS version FROM goose_db_version v
+WHERE v.is_applied
+AND NOT EXISTS (
+    SELECT 1 FROM goose_db_version later
+    WHERE later.version_id = v.version_id AND later.id > v.id
+);
@@ -0,0 +1,39 @@
+-- name: UpsertSubscription :one
+INSERT INTO subscriptions (id, created_at, updated_at, user_id, plan, status, current_period_end)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id) DO UPDATE
+SET plan = EXCLUDED.plan,
+    status = EXCLUDED.status,
+    current_period_end = EXCLUDED.current_period_end,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetSubscriptionByUser :one
+SELECT * FROM subscriptions
+WHERE user_id = $1;
+
+-- name: UpdateSubscriptionStatus :one
+UPDATE subscriptions
+SET status = $2, updated_at = NOW()
+WHERE user_id = $1
+RETURNING *;
+
+-- name: ExpireLapsedSubscriptions :execrows
+UPDATE subscriptions
+SET status = 'expired', updated_at = NOW()
+WHERE status IN ('active', 'cancelled')
+AND current_period_end <= NOW();
+
+-- name: GetActivePlan :one
+SELECT plan FROM subscriptions
+WHERE user_id = $1
+AND status IN ('active', 'cancelled')
+AND current_period_end > NOW();
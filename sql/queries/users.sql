@@ -19,8 +19,26 @@ SET email = $2, hashed_password = $3, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
--- name: UpgradeUser :one
+-- name: UpdateUserPassword :exec
 UPDATE users
-SET is_chirpy_red = true, updated_at = NOW()
+SET hashed_password = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetMentionableUsers :many
+-- Users who have blocked the author can't be mentioned by them.
+SELECT * FROM users
+WHERE email = ANY(sqlc.arg(emails)::TEXT[])
+AND NOT EXISTS (
+    SELECT 1 FROM blocks
+    WHERE blocks.blocker_id = users.id AND blocks.blocked_id = sqlc.arg(author_id)
+);
+
+-- name: SetUserProtected :one
+UPDATE users
+SET protected = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
@@ -0,0 +1,48 @@
+-- name: CreateWebhookEvent :one
+INSERT INTO webhook_events (id, provider, event_id, event_type, payload, status, received_at, updated_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    'pending',
+    NOW(),
+    NOW()
+)
+ON CONFLICT (provider, event_id) DO NOTHING
+RETURNING *;
+
+-- name: GetWebhookEvent :one
+SELECT * FROM webhook_events
+WHERE id = $1;
+
+-- name: GetWebhookEventByEventID :one
+-- The row stays locked until the transaction ends, so a concurrent delivery
+-- of the same event waits to see whether this one was processed.
+SELECT * FROM webhook_events
+WHERE provider = $1 AND event_id = $2
+FOR UPDATE;
+
+-- name: GetWebhookEventForUpdate :one
+SELECT * FROM webhook_events
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListWebhookEventsByStatus :many
+SELECT * FROM webhook_events
+WHERE status = $1
+ORDER BY received_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: MarkWebhookEventProcessed :one
+UPDATE webhook_events
+SET status = $2, error = NULL, attempts = attempts + 1, processed_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: MarkWebhookEventFailed :one
+UPDATE webhook_events
+SET status = 'failed', error = $2, attempts = attempts + 1, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,32 @@
+-- +goose Up
+CREATE TABLE subscriptions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    plan TEXT NOT NULL,
+    status TEXT NOT NULL,
+    current_period_end TIMESTAMP NOT NULL
+);
+
+INSERT INTO subscriptions (id, created_at, updated_at, user_id, plan, status, current_period_end)
+SELECT gen_random_uuid(), NOW(), NOW(), id, 'chirpy_red', 'active', NOW() + INTERVAL '30 days'
+FROM users
+WHERE is_chirpy_red;
+
+ALTER TABLE users
+DROP COLUMN is_chirpy_red;
+
+-- +goose Down
+ALTER TABLE users
+ADD COLUMN is_chirpy_red BOOLEAN NOT NULL
+DEFAULT FALSE;
+
+UPDATE users
+SET is_chirpy_red = TRUE
+WHERE id IN (
+    SELECT user_id FROM subscriptions
+    WHERE status IN ('active', 'cancelled') AND current_period_end > NOW()
+);
+
+DROP TABLE subscriptions;
\ No newline at end of file
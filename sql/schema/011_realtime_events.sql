@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE SEQUENCE realtime_event_id_seq;
+
+-- +goose Down
+DROP SEQUENCE realtime_event_id_seq;
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN protected BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE follows ADD COLUMN status TEXT NOT NULL DEFAULT 'accepted';
+
+CREATE INDEX follows_pending_idx ON follows (followee_id) WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX follows_pending_idx;
+ALTER TABLE follows DROP COLUMN status;
+ALTER TABLE users DROP COLUMN protected;
\ No newline at end of file
@@ -0,0 +1,40 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN hidden_at TIMESTAMP;
+
+CREATE TABLE reports (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    chirp_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    reported_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- reporter_id is NULL for reports filed by the content filter.
+    reporter_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'actioned', 'dismissed')),
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    UNIQUE (chirp_id, reporter_id)
+);
+
+CREATE INDEX reports_open_idx ON reports (created_at) WHERE status = 'open';
+
+-- Actions aren't foreign keys, moderator included, so the trail outlives
+-- removed chirps, moderator accounts and database resets.
+CREATE TABLE moderation_actions (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    moderator_id UUID NOT NULL,
+    action TEXT NOT NULL,
+    chirp_id UUID,
+    user_id UUID,
+    report_id UUID,
+    note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX moderation_actions_created_idx ON moderation_actions (created_at DESC);
+
+-- +goose Down
+DROP TABLE moderation_actions;
+DROP TABLE reports;
+ALTER TABLE chirps DROP COLUMN hidden_at;
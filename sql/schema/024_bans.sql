@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN suspended_until TIMESTAMP;
+ALTER TABLE users ADD COLUMN banned_at TIMESTAMP;
+-- moderation_reason is shown to the user when a suspended or banned account is refused.
+ALTER TABLE users ADD COLUMN moderation_reason TEXT NOT NULL DEFAULT '';
+-- chirps_hidden hides the user's chirps from everyone else while the suspension or ban lasts.
+ALTER TABLE users ADD COLUMN chirps_hidden BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN chirps_hidden;
+ALTER TABLE users DROP COLUMN moderation_reason;
+ALTER TABLE users DROP COLUMN banned_at;
+ALTER TABLE users DROP COLUMN suspended_until;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN deleted_at TIMESTAMP;
+-- deleted_by is the author or the moderator who deleted the chirp. Only
+-- authors can restore their own deletions.
+ALTER TABLE chirps ADD COLUMN deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DELETE FROM chirps WHERE deleted_at IS NOT NULL;
+DROP INDEX chirps_deleted_at_idx;
+ALTER TABLE chirps DROP COLUMN deleted_by;
+ALTER TABLE chirps DROP COLUMN deleted_at;
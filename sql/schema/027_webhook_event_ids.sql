@@ -0,0 +1,9 @@
+-- +goose Up
+-- Deliveries without a provider event ID aren't deduplicated: two identical
+-- bodies can be two real events, like a second upgrade after a downgrade.
+-- NULLs never conflict under the (provider, event_id) unique constraint.
+ALTER TABLE webhook_events ALTER COLUMN event_id DROP NOT NULL;
+
+-- +goose Down
+UPDATE webhook_events SET event_id = 'local:' || id WHERE event_id IS NULL;
+ALTER TABLE webhook_events ALTER COLUMN event_id SET NOT NULL;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Flagged chirps are now filed as reports by the content filter, so move the
+-- flags that are still waiting for review over and drop chirp_flags.
+INSERT INTO reports (id, created_at, updated_at, chirp_id, reported_user_id, reason, details)
+SELECT gen_random_uuid(), f.created_at, f.created_at, f.chirp_id, c.user_id, 'filtered', array_to_string(f.words, ', ')
+FROM chirp_flags f
+JOIN chirps c ON c.id = f.chirp_id;
+
+DROP TABLE chirp_flags;
+
+-- +goose Down
+CREATE TABLE chirp_flags (
+    chirp_id UUID PRIMARY KEY REFERENCES chirps(id) ON DELETE CASCADE,
+    words TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO chirp_flags (chirp_id, words, created_at)
+SELECT chirp_id, string_to_array(details, ', '), created_at
+FROM reports
+WHERE reporter_id IS NULL AND reason = 'filtered' AND status = 'open' AND chirp_id IS NOT NULL;
+
+DELETE FROM reports WHERE reporter_id IS NULL AND reason = 'filtered';
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Editing a chirp refiles its filter report, so keep at most one open filter
+-- report per chirp. Older duplicates are dismissed; the newest has the
+-- current words.
+UPDATE reports r
+SET status = 'dismissed', resolved_at = NOW(), updated_at = NOW()
+WHERE r.reporter_id IS NULL AND r.reason = 'filtered' AND r.status = 'open'
+AND EXISTS (
+    SELECT 1 FROM reports newer
+    WHERE newer.chirp_id = r.chirp_id
+    AND newer.reporter_id IS NULL AND newer.reason = 'filtered' AND newer.status = 'open'
+    AND (newer.created_at, newer.id) > (r.created_at, r.id)
+);
+
+CREATE UNIQUE INDEX reports_open_filter_idx ON reports (chirp_id)
+WHERE reporter_id IS NULL AND reason = 'filtered' AND status = 'open';
+
+-- +goose Down
+DROP INDEX reports_open_filter_idx;
@@ -0,0 +1,53 @@
+-- +goose Up
+-- chirp_visible_to is the visibility rule every chirp read shares. Authors
+-- always see their own chirps. Everyone else sees a chirp when it hasn't been
+-- hidden by a moderator and its visibility allows it, and public or unlisted
+-- chirps from protected accounts only reach accepted followers. A suspension
+-- or ban can also hide all of the author's chirps while it lasts. Deleted
+-- chirps and mutes are left to the queries, since they don't depend on who's
+-- asking in the same way.
+-- +goose StatementBegin
+CREATE FUNCTION chirp_visible_to(chirp UUID, viewer UUID) RETURNS BOOLEAN AS $$
+SELECT EXISTS (
+    SELECT 1 FROM chirps c
+    JOIN users u ON u.id = c.user_id
+    WHERE c.id = chirp
+    AND (
+        c.user_id = viewer
+        OR (
+            c.hidden_at IS NULL
+            AND NOT (u.chirps_hidden AND (u.banned_at IS NOT NULL OR COALESCE(u.suspended_until > NOW(), false)))
+            AND (
+                (
+                    c.visibility IN ('public', 'unlisted')
+                    AND (
+                        NOT u.protected
+                        OR EXISTS (
+                            SELECT 1 FROM follows f
+                            WHERE f.follower_id = viewer AND f.followee_id = c.user_id AND f.status = 'accepted'
+                        )
+                    )
+                )
+                OR (
+                    c.visibility = 'followers'
+                    AND EXISTS (
+                        SELECT 1 FROM follows f
+                        WHERE f.follower_id = viewer AND f.followee_id = c.user_id AND f.status = 'accepted'
+                    )
+                )
+                OR (
+                    c.visibility = 'mentioned'
+                    AND EXISTS (
+                        SELECT 1 FROM chirp_mentions m
+                        WHERE m.chirp_id = c.id AND m.user_id = viewer
+                    )
+                )
+            )
+        )
+    )
+)
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION chirp_visible_to(UUID, UUID);
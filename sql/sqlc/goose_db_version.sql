@@ -0,0 +1,8 @@
+-- goose creates and manages this table itself. It's declared here, outside
+-- sql/schema, only so sqlc can type-check queries that read it.
+CREATE TABLE goose_db_version (
+    id SERIAL PRIMARY KEY,
+    version_id BIGINT NOT NULL,
+    is_applied BOOLEAN NOT NULL,
+    tstamp TIMESTAMP DEFAULT NOW()
+);
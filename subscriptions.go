@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/google/uuid"
+)
+
+type Subscription struct {
+	UserID           uuid.UUID `json:"user_id"`
+	Plan             string    `json:"plan"`
+	Status           string    `json:"status"`
+	CurrentPeriodEnd time.Time `json:"current_period_end"`
+}
+
+const (
+	subscriptionStatusActive    = "active"
+	subscriptionStatusCancelled = "cancelled"
+	subscriptionStatusExpired   = "expired"
+
+	subscriptionBillingPeriod = 30 * 24 * time.Hour
+)
+
+// runSubscriptionExpiry periodically marks subscriptions whose paid period has
+// ended as expired. Red status is already derived from current_period_end, so
+// this keeps the stored status honest rather than gating access.
+func (cfg *apiConfig) runSubscriptionExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		expired, err := cfg.db.ExpireLapsedSubscriptions(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to expire lapsed subscriptions", "error", err)
+		} else if expired > 0 {
+			slog.InfoContext(ctx, "expired lapsed subscriptions", "count", expired)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func mapSubscription(sub database.Subscription) Subscription {
+	return Subscription{
+		UserID:           sub.UserID,
+		Plan:             sub.Plan,
+		Status:           sub.Status,
+		CurrentPeriodEnd: sub.CurrentPeriodEnd,
+	}
+}
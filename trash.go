@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/realtime"
+	"github.com/CybrRonin/Chirpy/internal/webhooks"
+	"github.com/google/uuid"
+)
+
+// handlerChirpsRestore takes one of the caller's chirps back out of the trash.
+// Chirps removed by a moderator can't be restored this way.
+func (cfg *apiConfig) handlerChirpsRestore(w http.ResponseWriter, req *http.Request) {
+	userID, ok := cfg.requireUser(w, req)
+	if !ok {
+		return
+	}
+
+	chirpID, err := uuid.Parse(req.PathValue("chirpID"))
+	if err != nil {
+		respondWithError(w, req, http.StatusBadRequest, "invalid chirp ID", err)
+		return
+	}
+
+	var chirp database.Chirp
+	err = cfg.withTx(req.Context(), func(q database.Querier) error {
+		chirp, err = q.RestoreChirp(req.Context(), database.RestoreChirpParams{
+			ID:           chirpID,
+			UserID:       userID,
+			DeletedAfter: time.Now().UTC().Add(-cfg.chirpRestoreWindow),
+		})
+		if err != nil {
+			return err
+		}
+		return cfg.enqueueChirpWebhook(req.Context(), q, webhooks.EventChirpRestored, mapChirp(chirp))
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, req, http.StatusNotFound, "couldn't find a deleted chirp to restore", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, req, http.StatusInternalServerError, "couldn't restore chirp", err)
+		return
+	}
+
+	cfg.publishChirpEvent(req.Context(), realtime.EventChirpRestored, mapChirp(chirp))
+	respondWithJSON(w, http.StatusOK, mapChirp(chirp))
+}
+
+// runChirpPurge periodically hard-deletes chirps that have been in the trash
+// for longer than purgeAfter.
+func (cfg *apiConfig) runChirpPurge(ctx context.Context, interval, purgeAfter time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := cfg.db.PurgeDeletedChirps(ctx, time.Now().UTC().Add(-purgeAfter))
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to purge deleted chirps", "error", err)
+		} else if purged > 0 {
+			slog.InfoContext(ctx, "purged deleted chirps", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/CybrRonin/Chirpy/internal/database"
+	"github.com/CybrRonin/Chirpy/internal/tracing"
+)
+
+// txRunner runs fn inside a transaction, committing if it returns nil.
+type txRunner func(ctx context.Context, fn func(q database.Querier) error) error
+
+// sqlTxRunner runs transactions against db.
+func sqlTxRunner(db *sql.DB) txRunner {
+	return func(ctx context.Context, fn func(q database.Querier) error) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		err = fn(database.New(tracing.WrapDBTX(tx)))
+		if err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+}